@@ -1,13 +1,25 @@
 package main
 
 import (
+	"context"
 	"log"
 
+	"task-tracker/internal/attachments"
 	"task-tracker/internal/auth"
+	"task-tracker/internal/calendar"
+	"task-tracker/internal/comments"
 	"task-tracker/internal/config"
 	"task-tracker/internal/db"
+	"task-tracker/internal/events"
+	"task-tracker/internal/labels"
+	"task-tracker/internal/mail"
 	"task-tracker/internal/middleware"
+	"task-tracker/internal/projects"
+	"task-tracker/internal/reminders"
+	"task-tracker/internal/storage"
+	"task-tracker/internal/stream"
 	"task-tracker/internal/tasks"
+	"task-tracker/internal/webhooks"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -41,9 +53,61 @@ func main() {
 	}
 	defer dbConn.Close()
 
+	// Initialize attachment storage
+	var store storage.Storage
+	if cfg.StorageBackend == "s3" {
+		store, err = storage.NewS3(cfg.S3Endpoint, cfg.S3Bucket, cfg.S3Region, cfg.S3AccessKey, cfg.S3SecretKey)
+	} else {
+		store, err = storage.NewLocal(cfg.StorageDir)
+	}
+	if err != nil {
+		logger.Fatal("Failed to initialize storage", zap.Error(err))
+	}
+
+	// Initialize the mail sender
+	var mailer mail.Sender
+	if cfg.MailBackend == "smtp" {
+		mailer, err = mail.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		mailer, err = mail.NewFile(cfg.MailDir, cfg.MailFrom, logger)
+	}
+	if err != nil {
+		logger.Fatal("Failed to initialize mail sender", zap.Error(err))
+	}
+
+	// Connect the event bus shared by all instances
+	bus, err := events.NewPostgres(cfg.DatabaseURL, dbConn, logger)
+	if err != nil {
+		logger.Fatal("Failed to start event bus", zap.Error(err))
+	}
+
 	// Initialize services
-	authService := auth.NewService(dbConn, cfg.JWTSecret, logger)
-	taskService := tasks.NewService(dbConn, logger)
+	if cfg.MFASecretKey == "" {
+		logger.Warn("MFA_SECRET_KEY is not set; two-factor authentication endpoints answer 503 Service Unavailable")
+	}
+	authService := auth.NewService(dbConn, bus, mailer, cfg.JWTSecret, cfg.AppURL,
+		cfg.RequireVerified == "login", cfg.MFASecretKey, logger)
+	taskService := tasks.NewService(dbConn, bus, cfg.RequireIfMatch, logger)
+	labelService := labels.NewService(dbConn, logger)
+	projectService := projects.NewService(dbConn, taskService, logger)
+	commentService := comments.NewService(dbConn, logger)
+	reminderService := reminders.NewService(dbConn, logger)
+	webhookService := webhooks.NewService(dbConn, logger)
+	calendarService := calendar.NewService(dbConn, logger)
+	eventHub := stream.NewHub(dbConn, bus, logger)
+	attachmentService := attachments.NewService(dbConn, store, cfg.AttachmentMaxBytes, cfg.AttachmentTypes, logger)
+
+	// Start background jobs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bus.Run(ctx)
+	go authService.RunTokenCleanup(ctx)
+	go authService.RunSessionSync(ctx)
+	go taskService.RunTrashPurger(ctx, cfg.TrashRetention)
+	go attachmentService.RunCleanup(ctx)
+	go reminderService.RunScheduler(ctx, cfg.ReminderInterval)
+	go webhookService.RunDispatcher(ctx)
+	go eventHub.Run(ctx)
 
 	// Initialize Gin
 	r := gin.Default()
@@ -51,7 +115,8 @@ func main() {
 	// Configure CORS
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"http://localhost:3000"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "If-Match", "Last-Event-ID"}
+	corsConfig.ExposeHeaders = []string{"ETag"}
 	r.Use(cors.New(corsConfig))
 	r.Use(middleware.MetricsMiddleware())
 
@@ -62,16 +127,99 @@ func main() {
 	r.POST("/register", auth.RegisterHandler(authService))
 	r.POST("/login", auth.LoginHandler(authService))
 	r.POST("/refresh", auth.RefreshHandler(authService))
+	r.POST("/logout", auth.LogoutHandler(authService))
+	r.POST("/password/forgot", auth.ForgotPasswordHandler(authService))
+	r.POST("/password/reset", auth.ResetPasswordHandler(authService))
+	r.POST("/email/verify", auth.VerifyEmailHandler(authService))
+	r.POST("/email/verify/resend", auth.ResendVerificationHandler(authService))
+	r.POST("/mfa/verify", auth.VerifyMFAHandler(authService))
+
+	// Calendar feed; the secret URL is the credential
+	r.GET("/calendar/:token", calendar.FeedHandler(calendarService))
+
+	// Event stream; authenticates by header or single-use ticket query parameter
+	r.GET("/events", middleware.StreamAuthMiddleware(authService), stream.EventsHandler(eventHub))
 
 	// Protected routes
 	protected := r.Group("/")
 	protected.Use(middleware.AuthMiddleware(authService))
 	{
+		// Creating tasks may require a verified email address
+		verified := middleware.VerifiedEmailMiddleware(authService, cfg.RequireVerified == "tasks")
+
 		protected.GET("/tasks", tasks.GetTasksHandler(taskService))
-		protected.POST("/tasks", tasks.CreateTaskHandler(taskService))
+		protected.POST("/tasks", verified, tasks.CreateTaskHandler(taskService))
+		protected.POST("/tasks/bulk", verified, tasks.BulkTasksHandler(taskService))
+		protected.GET("/tasks/search", tasks.SearchTasksHandler(taskService))
+		protected.GET("/tasks/export", tasks.ExportTasksHandler(taskService))
+		protected.POST("/tasks/import", verified, tasks.ImportTasksHandler(taskService))
+		protected.GET("/tasks/trash", tasks.GetTrashHandler(taskService))
 		protected.GET("/tasks/:id", tasks.GetTaskHandler(taskService))
 		protected.PUT("/tasks/:id", tasks.UpdateTaskHandler(taskService))
+		protected.PATCH("/tasks/:id", tasks.PatchTaskHandler(taskService))
 		protected.DELETE("/tasks/:id", tasks.DeleteTaskHandler(taskService))
+		protected.POST("/tasks/:id/restore", tasks.RestoreTaskHandler(taskService))
+		protected.GET("/tasks/:id/history", tasks.GetHistoryHandler(taskService))
+		protected.GET("/tasks/:id/children", tasks.GetChildrenHandler(taskService))
+		protected.GET("/tasks/:id/tree", tasks.GetTreeHandler(taskService))
+		protected.GET("/tasks/:id/occurrences", tasks.GetOccurrencesHandler(taskService))
+		protected.DELETE("/tasks/:id/recurrence", tasks.StopRecurrenceHandler(taskService))
+		protected.GET("/tasks/:id/links", tasks.GetLinksHandler(taskService))
+		protected.POST("/tasks/:id/links", tasks.AddLinkHandler(taskService))
+		protected.DELETE("/tasks/:id/links/:link_id", tasks.RemoveLinkHandler(taskService))
+		protected.PUT("/tasks/:id/labels/:label_id", tasks.AttachLabelHandler(taskService))
+		protected.DELETE("/tasks/:id/labels/:label_id", tasks.DetachLabelHandler(taskService))
+		protected.GET("/tasks/:id/comments", comments.GetCommentsHandler(commentService))
+		protected.POST("/tasks/:id/comments", comments.AddCommentHandler(commentService))
+		protected.PUT("/tasks/:id/comments/:comment_id", comments.EditCommentHandler(commentService))
+		protected.DELETE("/tasks/:id/comments/:comment_id", comments.DeleteCommentHandler(commentService))
+		protected.GET("/tasks/:id/reminders", reminders.GetRemindersHandler(reminderService))
+		protected.POST("/tasks/:id/reminders", reminders.AddReminderHandler(reminderService))
+		protected.DELETE("/tasks/:id/reminders/:reminder_id", reminders.DeleteReminderHandler(reminderService))
+		protected.GET("/tasks/:id/attachments", attachments.GetAttachmentsHandler(attachmentService))
+		protected.POST("/tasks/:id/attachments", attachments.UploadAttachmentHandler(attachmentService))
+		protected.GET("/tasks/:id/attachments/:attachment_id", attachments.DownloadAttachmentHandler(attachmentService))
+		protected.DELETE("/tasks/:id/attachments/:attachment_id", attachments.DeleteAttachmentHandler(attachmentService))
+
+		protected.GET("/mfa", auth.GetMFAHandler(authService))
+		protected.POST("/mfa/totp/enroll", auth.EnrollTOTPHandler(authService))
+		protected.POST("/mfa/totp/confirm", auth.ConfirmTOTPHandler(authService))
+		protected.POST("/mfa/totp/disable", auth.DisableMFAHandler(authService))
+		protected.POST("/mfa/recovery-codes", auth.RegenerateRecoveryCodesHandler(authService))
+
+		protected.GET("/sessions", auth.GetSessionsHandler(authService))
+		protected.DELETE("/sessions", auth.RevokeAllSessionsHandler(authService))
+		protected.DELETE("/sessions/:id", auth.RevokeSessionHandler(authService))
+
+		protected.POST("/events/ticket", auth.StreamTicketHandler(authService))
+
+		protected.GET("/calendar/feed", calendar.GetFeedHandler(calendarService))
+		protected.POST("/calendar/feed", calendar.RegenerateFeedHandler(calendarService))
+		protected.DELETE("/calendar/feed", calendar.RevokeFeedHandler(calendarService))
+
+		protected.GET("/notifications", reminders.GetNotificationsHandler(reminderService))
+		protected.POST("/notifications/:id/read", reminders.MarkReadHandler(reminderService))
+
+		protected.GET("/webhooks", webhooks.GetWebhooksHandler(webhookService))
+		protected.POST("/webhooks", webhooks.CreateWebhookHandler(webhookService))
+		protected.PUT("/webhooks/:id", webhooks.UpdateWebhookHandler(webhookService))
+		protected.DELETE("/webhooks/:id", webhooks.DeleteWebhookHandler(webhookService))
+		protected.GET("/webhooks/:id/deliveries", webhooks.GetDeliveriesHandler(webhookService))
+		protected.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhooks.RedeliverHandler(webhookService))
+
+		protected.GET("/labels", labels.GetLabelsHandler(labelService))
+		protected.POST("/labels", labels.CreateLabelHandler(labelService))
+		protected.PUT("/labels/:id", labels.UpdateLabelHandler(labelService))
+		protected.DELETE("/labels/:id", labels.DeleteLabelHandler(labelService))
+
+		protected.GET("/projects", projects.GetProjectsHandler(projectService))
+		protected.POST("/projects", projects.CreateProjectHandler(projectService))
+		protected.GET("/projects/:id", projects.GetProjectHandler(projectService))
+		protected.PUT("/projects/:id", projects.UpdateProjectHandler(projectService))
+		protected.DELETE("/projects/:id", projects.DeleteProjectHandler(projectService))
+		protected.POST("/projects/:id/archive", projects.ArchiveProjectHandler(projectService, true))
+		protected.POST("/projects/:id/unarchive", projects.ArchiveProjectHandler(projectService, false))
+		protected.GET("/projects/:id/tasks", tasks.GetProjectTasksHandler(taskService))
 	}
 
 	// Start server
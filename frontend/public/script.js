@@ -23,7 +23,11 @@ function formatDateTime(dateTime) {
 async function register() {
     const username = document.getElementById('username').value;
     const password = document.getElementById('password').value;
-    const email = prompt('Enter email (optional):');
+    const email = document.getElementById('email').value.trim();
+    if (!email) {
+        setMessage('auth-message', 'Please enter your email address', true);
+        return;
+    }
     try {
         const response = await fetch('http://localhost:8080/register', {
             method: 'POST',
@@ -31,6 +35,22 @@ async function register() {
             body: JSON.stringify({ username, password_hash: password, email })
         });
         const data = await response.json();
+        setMessage('auth-message', response.ok ? 'Registered! Check your email for a confirmation link.' : data.error, !response.ok);
+    } catch (error) {
+        setMessage('auth-message', 'Error: ' + error.message, true);
+    }
+}
+
+async function resendVerification() {
+    const email = document.getElementById('email').value.trim() || prompt('Enter the email of your account:');
+    if (!email) return;
+    try {
+        const response = await fetch('http://localhost:8080/email/verify/resend', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ email })
+        });
+        const data = await response.json();
         setMessage('auth-message', data.message || data.error, !response.ok);
     } catch (error) {
         setMessage('auth-message', 'Error: ' + error.message, true);
@@ -46,12 +66,17 @@ async function login() {
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({ username, password })
         });
-        const data = await response.json();
+        let data = await response.json();
+        if (response.ok && data.mfa_required) {
+            data = await verifyMFA(data.mfa_token);
+            if (!data) return;
+        }
         if (response.ok) {
             saveTokens(data.access_token, data.refresh_token);
             document.getElementById('auth').style.display = 'none';
             document.getElementById('tasks').style.display = 'block';
             loadTasks();
+            subscribeEvents();
         } else {
             setMessage('auth-message', data.error, true);
         }
@@ -60,8 +85,114 @@ async function login() {
     }
 }
 
-async function refreshTokenIfNeeded() {
-    if (!refreshToken) return false;
+async function forgotPassword() {
+    const email = prompt('Enter the email of your account:');
+    if (!email) return;
+    try {
+        const response = await fetch('http://localhost:8080/password/forgot', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ email })
+        });
+        const data = await response.json();
+        setMessage('auth-message', data.message || data.error, !response.ok);
+    } catch (error) {
+        setMessage('auth-message', 'Error: ' + error.message, true);
+    }
+}
+
+// Links from the verification email open the app with ?verify_token=...
+async function verifyEmailFromLink() {
+    const params = new URLSearchParams(window.location.search);
+    const token = params.get('verify_token');
+    if (!token) return;
+    history.replaceState(null, '', window.location.pathname);
+    try {
+        const response = await fetch('http://localhost:8080/email/verify', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ token })
+        });
+        const data = await response.json();
+        setMessage('auth-message', data.message || data.error, !response.ok);
+    } catch (error) {
+        setMessage('auth-message', 'Error: ' + error.message, true);
+    }
+}
+
+verifyEmailFromLink();
+
+// Reset links from the password reset email open the app with ?reset_token=...
+async function resetPasswordFromLink() {
+    const params = new URLSearchParams(window.location.search);
+    const token = params.get('reset_token');
+    if (!token) return;
+    history.replaceState(null, '', window.location.pathname);
+    const password = prompt('Enter a new password:');
+    if (!password) return;
+    try {
+        const response = await fetch('http://localhost:8080/password/reset', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ token, password })
+        });
+        const data = await response.json();
+        setMessage('auth-message', data.message || data.error, !response.ok);
+    } catch (error) {
+        setMessage('auth-message', 'Error: ' + error.message, true);
+    }
+}
+
+resetPasswordFromLink();
+
+async function logout() {
+    try {
+        await fetch('http://localhost:8080/logout', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ refresh_token: refreshToken })
+        });
+    } catch (error) {
+        console.error('Logout failed:', error);
+    }
+    if (eventSource) eventSource.close();
+    eventSource = null;
+    lastEventId = '';
+    saveTokens('', '');
+    document.getElementById('tasks').style.display = 'none';
+    document.getElementById('auth').style.display = 'block';
+}
+
+// Accounts with two-factor authentication exchange the login's MFA token and a code for tokens.
+async function verifyMFA(mfaToken) {
+    const code = prompt('Enter the code from your authenticator app or a recovery code:');
+    if (!code) return null;
+    const response = await fetch('http://localhost:8080/mfa/verify', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ mfa_token: mfaToken, code })
+    });
+    const data = await response.json();
+    if (!response.ok) {
+        setMessage('auth-message', data.error, true);
+        return null;
+    }
+    return data;
+}
+
+// Refresh tokens are single-use, so concurrent callers must share one request;
+// replaying a rotated-out token would revoke the whole session.
+let refreshInFlight = null;
+
+function refreshTokenIfNeeded() {
+    if (!refreshToken) return Promise.resolve(false);
+    if (!refreshInFlight) {
+        refreshInFlight = doRefresh().finally(() => { refreshInFlight = null; });
+    }
+    return refreshInFlight;
+}
+
+async function doRefresh() {
     try {
         const response = await fetch('http://localhost:8080/refresh', {
             method: 'POST',
@@ -80,21 +211,80 @@ async function refreshTokenIfNeeded() {
     }
 }
 
+let eventSource = null;
+let reloadTimer = null;
+let lastEventId = '';
+
+async function fetchStreamTicket() {
+    const request = () => fetch('http://localhost:8080/events/ticket', {
+        method: 'POST',
+        headers: { 'Authorization': `Bearer ${accessToken}` }
+    });
+    let response = await request();
+    if (response.status === 401 && await refreshTokenIfNeeded()) {
+        response = await request();
+    }
+    if (!response.ok) return null;
+    const data = await response.json();
+    return data.ticket;
+}
+
+async function subscribeEvents() {
+    if (eventSource) eventSource.close();
+    eventSource = null;
+    let ticket;
+    try {
+        ticket = await fetchStreamTicket();
+    } catch (error) {
+        console.error('Event stream ticket failed:', error);
+    }
+    if (!ticket) return;
+
+    let url = `http://localhost:8080/events?ticket=${encodeURIComponent(ticket)}`;
+    if (lastEventId) url += `&last_event_id=${encodeURIComponent(lastEventId)}`;
+    const source = new EventSource(url);
+    eventSource = source;
+    const scheduleReload = (event) => {
+        if (event.lastEventId) lastEventId = event.lastEventId;
+        clearTimeout(reloadTimer);
+        reloadTimer = setTimeout(loadTasks, 200);
+    };
+    ['task.created', 'task.updated', 'task.deleted', 'reset'].forEach(type => {
+        source.addEventListener(type, scheduleReload);
+    });
+    source.onerror = () => {
+        // Tickets are single-use, so the browser's own reconnect is rejected; connect with a new one.
+        if (source.readyState === EventSource.CLOSED && eventSource === source) {
+            setTimeout(subscribeEvents, 1000);
+        }
+    };
+}
+
 async function loadTasks() {
     if (!accessToken) {
         setMessage('task-message', 'Please login first', true);
         return;
     }
     try {
-        const response = await fetch('http://localhost:8080/tasks', {
-            headers: { 'Authorization': `Bearer ${accessToken}` }
-        });
-        if (response.status === 401) {
-            if (await refreshTokenIfNeeded()) {
-                return loadTasks();
+        // Follow next_cursor until the last page so no task is left out.
+        let tasks = [];
+        let cursor = '';
+        let response, data;
+        do {
+            const page = cursor ? `&cursor=${encodeURIComponent(cursor)}` : '';
+            response = await fetch(`http://localhost:8080/tasks?sort=priority&order=desc&limit=200${page}`, {
+                headers: { 'Authorization': `Bearer ${accessToken}` }
+            });
+            if (response.status === 401) {
+                if (await refreshTokenIfNeeded()) {
+                    return loadTasks();
+                }
             }
-        }
-        const data = await response.json();
+            data = await response.json();
+            if (!response.ok) break;
+            tasks = tasks.concat(Array.isArray(data.tasks) ? data.tasks : []);
+            cursor = data.next_cursor;
+        } while (cursor);
         const pendingTasks = document.getElementById('pending-tasks');
         const inProgressTasks = document.getElementById('in_progress-tasks');
         const doneTasks = document.getElementById('done-tasks');
@@ -102,7 +292,6 @@ async function loadTasks() {
         inProgressTasks.innerHTML = '';
         doneTasks.innerHTML = '';
         if (response.ok) {
-            let tasks = Array.isArray(data) ? data : [];
             tasks.sort((a, b) => b.priority - a.priority);
             tasks.forEach(task => {
                 const div = document.createElement('div');
@@ -121,11 +310,11 @@ async function loadTasks() {
                 const updateBtn = document.createElement('button');
                 updateBtn.textContent = 'Update';
                 updateBtn.className = 'bg-yellow-500 text-white p-1 rounded hover:bg-yellow-600';
-                updateBtn.onclick = () => openModal(task.id, task.title, task.description || '', task.status, task.priority, task.due_date || '');
+                updateBtn.onclick = () => openModal(task.id, task.title, task.description || '', task.status, task.priority, task.due_date || '', task.version);
                 const deleteBtn = document.createElement('button');
                 deleteBtn.textContent = 'Delete';
                 deleteBtn.className = 'bg-red-500 text-white p-1 ml-2 rounded hover:bg-red-600';
-                deleteBtn.onclick = () => deleteTask(task.id);
+                deleteBtn.onclick = () => deleteTask(task.id, task.version);
                 div.appendChild(title);
                 div.appendChild(desc);
                 div.appendChild(status);
@@ -190,8 +379,9 @@ async function createTask() {
     }
 }
 
-function openModal(id, title, description, status, priority, due_date) {
+function openModal(id, title, description, status, priority, due_date, version) {
     document.getElementById('update-task-id').value = id;
+    document.getElementById('update-task-version').value = version;
     document.getElementById('update-title').value = title;
     document.getElementById('update-description').value = description;
     document.getElementById('update-status').value = status;
@@ -206,6 +396,7 @@ function closeModal() {
 
 async function saveUpdate() {
     const id = document.getElementById('update-task-id').value;
+    const version = document.getElementById('update-task-version').value;
     const task = {
         title: document.getElementById('update-title').value,
         description: document.getElementById('update-description').value,
@@ -218,7 +409,8 @@ async function saveUpdate() {
             method: 'PUT',
             headers: {
                 'Content-Type': 'application/json',
-                'Authorization': `Bearer ${accessToken}`
+                'Authorization': `Bearer ${accessToken}`,
+                'If-Match': `"${version}"`
             },
             body: JSON.stringify(task)
         });
@@ -232,6 +424,10 @@ async function saveUpdate() {
             closeModal();
             setMessage('task-message', 'Task updated!');
             loadTasks();
+        } else if (response.status === 412) {
+            closeModal();
+            setMessage('task-message', 'The task was changed elsewhere; reloaded the latest version', true);
+            loadTasks();
         } else {
             setMessage('task-message', data.error || 'Failed to update task', true);
         }
@@ -240,7 +436,7 @@ async function saveUpdate() {
     }
 }
 
-async function deleteTask(id) {
+async function deleteTask(id, version) {
     if (!accessToken) {
         setMessage('task-message', 'Please login first', true);
         return;
@@ -248,17 +444,20 @@ async function deleteTask(id) {
     try {
         const response = await fetch(`http://localhost:8080/tasks/${id}`, {
             method: 'DELETE',
-            headers: { 'Authorization': `Bearer ${accessToken}` }
+            headers: { 'Authorization': `Bearer ${accessToken}`, 'If-Match': `"${version}"` }
         });
         if (response.status === 401) {
             if (await refreshTokenIfNeeded()) {
-                return deleteTask(id);
+                return deleteTask(id, version);
             }
         }
         const data = await response.json();
         if (response.ok) {
             setMessage('task-message', 'Task deleted!');
             loadTasks();
+        } else if (response.status === 412) {
+            setMessage('task-message', 'The task was changed elsewhere; reloaded the latest version', true);
+            loadTasks();
         } else {
             setMessage('task-message', data.error || 'Failed to delete task', true);
         }
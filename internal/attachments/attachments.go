@@ -0,0 +1,155 @@
+package attachments
+
+import (
+	"database/sql"
+	"errors"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"task-tracker/internal/storage"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	formField         = "file"
+	multipartOverhead = 1 << 20
+	maxFilenameLength = 255
+)
+
+//! \fn GetAttachmentsHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list a task's attachments.
+//! \param s Attachment service instance.
+//! \return Gin handler function.
+func GetAttachmentsHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		attachments, err := s.GetAttachments(c.Param("id"), userID.(int))
+		if err != nil {
+			respondError(c, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, attachments)
+	}
+}
+
+//! \fn UploadAttachmentHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to upload a file to a task as multipart form field "file".
+//! \param s Attachment service instance.
+//! \return Gin handler function.
+func UploadAttachmentHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, s.MaxBytes()+multipartOverhead)
+
+		header, err := c.FormFile(formField)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				respondError(c, ErrTooLarge, "")
+				return
+			}
+			s.logger.Warn("Invalid upload", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field \"file\" is required"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			s.logger.Error("Failed to open upload", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload"})
+			return
+		}
+		defer file.Close()
+
+		userID, _ := c.Get("user_id")
+		attachment, err := s.Upload(c.Request.Context(), c.Param("id"), userID.(int),
+			cleanFilename(header.Filename), header.Size, file)
+		if err != nil {
+			respondError(c, err, "Failed to upload attachment")
+			return
+		}
+		c.JSON(http.StatusCreated, attachment)
+	}
+}
+
+//! \fn DownloadAttachmentHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to download an attachment.
+//! \param s Attachment service instance.
+//! \return Gin handler function.
+func DownloadAttachmentHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		attachment, body, err := s.Open(c.Request.Context(), c.Param("id"), c.Param("attachment_id"), userID.(int))
+		if err != nil {
+			respondError(c, err, "Failed to download attachment")
+			return
+		}
+		defer body.Close()
+
+		// Always download rather than render, so uploaded HTML or SVG cannot run in our origin.
+		headers := map[string]string{
+			"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+			"X-Content-Type-Options": "nosniff",
+		}
+		c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, body, headers)
+	}
+}
+
+//! \fn DeleteAttachmentHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to delete an attachment.
+//! \param s Attachment service instance.
+//! \return Gin handler function.
+func DeleteAttachmentHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		if err := s.DeleteAttachment(c.Request.Context(), c.Param("id"), c.Param("attachment_id"), userID.(int)); err != nil {
+			respondError(c, err, "Failed to delete attachment")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted"})
+	}
+}
+
+//! \fn cleanFilename(name string) string
+//! \brief Reduces a client-supplied file name to a safe base name.
+//! \param name Original file name.
+//! \return Sanitized file name.
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" || name == "" {
+		name = "attachment"
+	}
+	if len(name) > maxFilenameLength {
+		name = strings.ToValidUTF8(name[len(name)-maxFilenameLength:], "")
+	}
+	return name
+}
+
+//! \fn respondError(c *gin.Context, err error, message string)
+//! \brief Writes the response for a failed attachment operation.
+//! \param c Gin context.
+//! \param err Error returned by the service.
+//! \param message Message used for unexpected errors.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, storage.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+	case errors.Is(err, ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrEmptyFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,359 @@
+package attachments
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"task-tracker/internal/models"
+	"task-tracker/internal/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	sniffLength      = 512
+	cleanupInterval  = 10 * time.Minute
+	cleanupBatchSize = 100
+)
+
+//! \var ErrTaskNotFound
+//! \brief Returned when the task does not exist, is trashed or belongs to another user.
+var ErrTaskNotFound = errors.New("task not found")
+
+//! \var ErrTooLarge
+//! \brief Returned when an upload exceeds the configured size limit.
+var ErrTooLarge = errors.New("attachment too large")
+
+//! \var ErrUnsupportedType
+//! \brief Returned when the sniffed content type is not in the allowed list.
+var ErrUnsupportedType = errors.New("unsupported attachment type")
+
+//! \var ErrEmptyFile
+//! \brief Returned when an upload has no content.
+var ErrEmptyFile = errors.New("attachment is empty")
+
+//! \var DefaultAllowedTypes
+//! \brief Content types accepted when no list is configured.
+var DefaultAllowedTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"application/zip",
+	"application/x-gzip",
+	"text/plain",
+}
+
+//! \struct Service
+//! \brief Handles task attachment business logic.
+type Service struct {
+	db           *sql.DB
+	store        storage.Storage
+	maxBytes     int64
+	allowedTypes map[string]bool
+	logger       *zap.Logger
+}
+
+//! \fn NewService(db *sql.DB, store storage.Storage, maxBytes int64, allowedTypes []string, logger *zap.Logger) *Service
+//! \brief Initializes a new attachment service.
+//! \param db Database connection.
+//! \param store Blob storage backend.
+//! \param maxBytes Maximum size of a single attachment.
+//! \param allowedTypes Accepted sniffed content types; DefaultAllowedTypes if empty.
+//! \param logger Logger instance.
+//! \return Pointer to initialized Service.
+func NewService(db *sql.DB, store storage.Storage, maxBytes int64, allowedTypes []string, logger *zap.Logger) *Service {
+	if len(allowedTypes) == 0 {
+		allowedTypes = DefaultAllowedTypes
+	}
+	allowed := make(map[string]bool, len(allowedTypes))
+	for _, t := range allowedTypes {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return &Service{
+		db:           db,
+		store:        store,
+		maxBytes:     maxBytes,
+		allowedTypes: allowed,
+		logger:       logger,
+	}
+}
+
+//! \fn MaxBytes() int64
+//! \brief Reports the configured attachment size limit.
+//! \return Maximum size in bytes.
+func (s *Service) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+//! \fn checkTask(taskID string, userID int) error
+//! \brief Verifies that the user may access the task, following the task service's ownership rules.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \return ErrTaskNotFound or another error (if any).
+func (s *Service) checkTask(taskID string, userID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+	if err := s.db.QueryRow(query, taskID, userID).Scan(&exists); err != nil {
+		s.logger.Error("Failed to check task ownership", zap.Error(err))
+		return err
+	}
+	if !exists {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+//! \fn GetAttachments(taskID string, userID int) ([]models.Attachment, error)
+//! \brief Retrieves the attachments of a task, oldest first.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \return List of attachments and error (if any).
+func (s *Service) GetAttachments(taskID string, userID int) ([]models.Attachment, error) {
+	if err := s.checkTask(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, task_id, uploader_id, filename, content_type, size, storage_key, created_at
+              FROM task_attachments WHERE task_id = $1 ORDER BY id`
+	rows, err := s.db.Query(query, taskID)
+	if err != nil {
+		s.logger.Error("Failed to fetch attachments", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(&a.ID, &a.TaskID, &a.UploaderID, &a.Filename, &a.ContentType,
+			&a.Size, &a.StorageKey, &a.CreatedAt); err != nil {
+			s.logger.Error("Failed to scan attachment", zap.Error(err))
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate attachments", zap.Error(err))
+		return nil, err
+	}
+	return attachments, nil
+}
+
+//! \fn Upload(ctx context.Context, taskID string, userID int, filename string, size int64, r io.Reader) (*models.Attachment, error)
+//! \brief Stores a file and attaches it to a task. The content type is sniffed from the data,
+//!        never taken from the client.
+//! \param ctx Request context.
+//! \param taskID ID of the task.
+//! \param userID ID of the uploader.
+//! \param filename Original file name.
+//! \param size Content length.
+//! \param r File content.
+//! \return Stored attachment and error (if any).
+func (s *Service) Upload(ctx context.Context, taskID string, userID int, filename string, size int64, r io.Reader) (*models.Attachment, error) {
+	if size > s.maxBytes {
+		return nil, ErrTooLarge
+	}
+	if size <= 0 {
+		return nil, ErrEmptyFile
+	}
+	if err := s.checkTask(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !s.allowedTypes[mediaType] {
+		s.logger.Warn("Rejected attachment type", zap.String("content_type", contentType))
+		return nil, ErrUnsupportedType
+	}
+
+	key := uuid.New().String()
+	body := io.MultiReader(bytes.NewReader(head), r)
+	if err := s.store.Put(ctx, key, body, size, contentType); err != nil {
+		s.logger.Error("Failed to store attachment", zap.Error(err))
+		return nil, err
+	}
+
+	// The insert re-checks the task so a concurrent delete cannot leave a dangling row.
+	a := &models.Attachment{}
+	query := `INSERT INTO task_attachments (task_id, uploader_id, filename, content_type, size, storage_key)
+              SELECT id, $2, $3, $4, $5, $6 FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+              RETURNING id, task_id, uploader_id, filename, content_type, size, storage_key, created_at`
+	err = s.db.QueryRow(query, taskID, userID, filename, contentType, size, key).
+		Scan(&a.ID, &a.TaskID, &a.UploaderID, &a.Filename, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt)
+	if err != nil {
+		if delErr := s.store.Delete(ctx, key); delErr != nil {
+			s.logger.Error("Failed to remove orphaned blob", zap.String("key", key), zap.Error(delErr))
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
+		s.logger.Error("Failed to save attachment", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Attachment uploaded", zap.Int("attachment_id", a.ID), zap.String("task_id", taskID),
+		zap.Int64("size", a.Size))
+	return a, nil
+}
+
+//! \fn Open(ctx context.Context, taskID, attachmentID string, userID int) (*models.Attachment, io.ReadCloser, error)
+//! \brief Opens an attachment for download.
+//! \param ctx Request context.
+//! \param taskID ID of the task.
+//! \param attachmentID ID of the attachment.
+//! \param userID ID of the user.
+//! \return Attachment metadata, content reader (closed by the caller) and error (if any).
+func (s *Service) Open(ctx context.Context, taskID, attachmentID string, userID int) (*models.Attachment, io.ReadCloser, error) {
+	if err := s.checkTask(taskID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	a := &models.Attachment{}
+	query := `SELECT id, task_id, uploader_id, filename, content_type, size, storage_key, created_at
+              FROM task_attachments WHERE id = $1 AND task_id = $2`
+	err := s.db.QueryRow(query, attachmentID, taskID).
+		Scan(&a.ID, &a.TaskID, &a.UploaderID, &a.Filename, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("Failed to fetch attachment", zap.Error(err))
+		}
+		return nil, nil, err
+	}
+
+	body, err := s.store.Get(ctx, a.StorageKey)
+	if err != nil {
+		s.logger.Error("Failed to open attachment blob", zap.String("key", a.StorageKey), zap.Error(err))
+		return nil, nil, err
+	}
+	return a, body, nil
+}
+
+//! \fn DeleteAttachment(ctx context.Context, taskID, attachmentID string, userID int) error
+//! \brief Detaches an attachment from its task and removes its blob.
+//! \param ctx Request context.
+//! \param taskID ID of the task.
+//! \param attachmentID ID of the attachment.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) DeleteAttachment(ctx context.Context, taskID, attachmentID string, userID int) error {
+	if err := s.checkTask(taskID, userID); err != nil {
+		return err
+	}
+
+	// Detaching first hides the attachment immediately; if the blob cannot be
+	// removed now, the cleanup sweep retries it later.
+	var id int
+	var key string
+	query := `UPDATE task_attachments SET task_id = NULL WHERE id = $1 AND task_id = $2 RETURNING id, storage_key`
+	if err := s.db.QueryRow(query, attachmentID, taskID).Scan(&id, &key); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("Failed to delete attachment", zap.Error(err))
+		}
+		return err
+	}
+	s.removeBlob(ctx, id, key)
+
+	s.logger.Info("Attachment deleted", zap.Int("attachment_id", id), zap.String("task_id", taskID))
+	return nil
+}
+
+//! \fn removeBlob(ctx context.Context, id int, key string) bool
+//! \brief Deletes a detached attachment's blob and then its row.
+//! \param ctx Request context.
+//! \param id ID of the attachment.
+//! \param key Storage key of the blob.
+//! \return True if both were removed.
+func (s *Service) removeBlob(ctx context.Context, id int, key string) bool {
+	if err := s.store.Delete(ctx, key); err != nil {
+		s.logger.Warn("Failed to delete attachment blob", zap.String("key", key), zap.Error(err))
+		return false
+	}
+	if _, err := s.db.Exec(`DELETE FROM task_attachments WHERE id = $1 AND task_id IS NULL`, id); err != nil {
+		s.logger.Error("Failed to delete attachment row", zap.Int("attachment_id", id), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+//! \fn Cleanup(ctx context.Context) (int, error)
+//! \brief Removes blobs of attachments whose task has been purged or which were deleted.
+//! \param ctx Context controlling the sweep.
+//! \return Number of removed attachments and error (if any).
+func (s *Service) Cleanup(ctx context.Context) (int, error) {
+	removed := 0
+	for {
+		query := `SELECT id, storage_key FROM task_attachments WHERE task_id IS NULL ORDER BY id LIMIT $1`
+		rows, err := s.db.QueryContext(ctx, query, cleanupBatchSize)
+		if err != nil {
+			s.logger.Error("Failed to fetch orphaned attachments", zap.Error(err))
+			return removed, err
+		}
+		type orphan struct {
+			id  int
+			key string
+		}
+		var orphans []orphan
+		for rows.Next() {
+			var o orphan
+			if err := rows.Scan(&o.id, &o.key); err != nil {
+				rows.Close()
+				s.logger.Error("Failed to scan orphaned attachment", zap.Error(err))
+				return removed, err
+			}
+			orphans = append(orphans, o)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			s.logger.Error("Failed to iterate orphaned attachments", zap.Error(err))
+			return removed, err
+		}
+
+		batch := 0
+		for _, o := range orphans {
+			if s.removeBlob(ctx, o.id, o.key) {
+				batch++
+			}
+		}
+		removed += batch
+		// Stop when the backlog is drained or nothing could be removed this round.
+		if len(orphans) < cleanupBatchSize || batch == 0 {
+			break
+		}
+	}
+	if removed > 0 {
+		s.logger.Info("Attachment blobs cleaned up", zap.Int("count", removed))
+	}
+	return removed, nil
+}
+
+//! \fn RunCleanup(ctx context.Context)
+//! \brief Periodically removes orphaned attachment blobs until the context is cancelled.
+//! \param ctx Context controlling the sweeper's lifetime.
+func (s *Service) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		s.Cleanup(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
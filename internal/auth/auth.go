@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
 	"task-tracker/internal/models"
@@ -13,11 +15,30 @@ import (
 //! \struct TokenClaims
 //! \brief Defines the structure for JWT claims.
 type TokenClaims struct {
-	UserID   int    `json:"user_id"`
-	Username string `json:"username"`
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+//! \struct LoginResult
+//! \brief Outcome of a login: either the session's tokens or, with two-factor authentication,
+//!        a challenge token to be exchanged at /mfa/verify.
+type LoginResult struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+//! \fn requestClient(c *gin.Context) Client
+//! \brief Describes the device a request comes from.
+//! \param c Gin context.
+//! \return Client description.
+func requestClient(c *gin.Context) Client {
+	return Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
+
 //! \fn RegisterHandler(s *Service) gin.HandlerFunc
 //! \brief Creates a Gin handler for user registration.
 //! \param s Authentication service instance.
@@ -72,19 +93,23 @@ func LoginHandler(s *Service) gin.HandlerFunc {
 			return
 		}
 
-		accessToken, refreshToken, err := s.Login(input.Username, input.Password)
+		result, err := s.Login(input.Username, input.Password, requestClient(c))
+		if errors.Is(err, ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Please verify your email address first"})
+			return
+		}
 		if err != nil {
 			s.Logger.Warn("Invalid credentials", zap.Error(err))
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"access_token": accessToken, "refresh_token": refreshToken})
+		c.JSON(http.StatusOK, result)
 	}
 }
 
 //! \fn RefreshHandler(s *Service) gin.HandlerFunc
-//! \brief Creates a Gin handler for refreshing access tokens.
+//! \brief Creates a Gin handler exchanging a refresh token for new access and refresh tokens.
 //! \param s Authentication service instance.
 //! \return Gin handler function.
 func RefreshHandler(s *Service) gin.HandlerFunc {
@@ -105,13 +130,447 @@ func RefreshHandler(s *Service) gin.HandlerFunc {
 			return
 		}
 
-		accessToken, err := s.Refresh(input.RefreshToken)
+		accessToken, refreshToken, err := s.Refresh(input.RefreshToken, requestClient(c))
 		if err != nil {
 			s.Logger.Warn("Invalid or expired refresh token", zap.Error(err))
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"access_token": accessToken})
+		c.JSON(http.StatusOK, gin.H{"access_token": accessToken, "refresh_token": refreshToken})
 	}
-}
\ No newline at end of file
+}
+
+//! \fn LogoutHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler ending the session of the presented refresh token.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func LogoutHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			RefreshToken string `json:"refresh_token" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			s.Logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&input); err != nil {
+			s.Logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := s.Logout(input.RefreshToken)
+		if errors.Is(err, ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+	}
+}
+
+//! \fn ForgotPasswordHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler requesting a password reset email. The response is the same
+//!        whether or not the address belongs to an account.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func ForgotPasswordHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			s.Logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&input); err != nil {
+			s.Logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		s.ForgotPassword(input.Email)
+		c.JSON(http.StatusAccepted, gin.H{"message": "If an account uses this email, a reset link has been sent"})
+	}
+}
+
+//! \fn ResetPasswordHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler setting a new password with a reset token.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func ResetPasswordHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Token    string `json:"token" validate:"required"`
+			Password string `json:"password" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			s.Logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&input); err != nil {
+			s.Logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := s.ResetPassword(input.Token, input.Password)
+		if errors.Is(err, ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed; please log in again"})
+	}
+}
+
+//! \fn VerifyEmailHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler confirming an email address with the token from the emailed link.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func VerifyEmailHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Token string `json:"token" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			s.Logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&input); err != nil {
+			s.Logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err := s.VerifyEmail(input.Token)
+		if errors.Is(err, ErrInvalidVerifyToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+	}
+}
+
+//! \fn ResendVerificationHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler requesting a new verification email. The response is the same
+//!        whether or not the address belongs to an unverified account.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func ResendVerificationHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			s.Logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&input); err != nil {
+			s.Logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := s.ResendVerification(input.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "If this address awaits verification, a new link has been sent"})
+	}
+}
+
+//! \fn GetSessionsHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler listing the user's active sessions.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func GetSessionsHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		sessions, err := s.ListSessions(userID.(int), c.GetString("session_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, sessions)
+	}
+}
+
+//! \fn RevokeSessionHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler ending one of the user's sessions.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func RevokeSessionHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		err := s.RevokeSession(userID.(int), c.Param("id"))
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+	}
+}
+
+//! \fn RevokeAllSessionsHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler logging the user out everywhere, including this session.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func RevokeAllSessionsHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		count, err := s.RevokeAllSessions(userID.(int))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out everywhere", "revoked": count})
+	}
+}
+
+//! \fn StreamTicketHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler issuing a single-use ticket for connecting to the event stream.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func StreamTicketHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		ticket, err := s.IssueStreamTicket(userID.(int), c.GetString("session_id"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue stream ticket"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"ticket": ticket, "expires_in": int(streamTicketTTL.Seconds())})
+	}
+}
+
+//! \fn mfaAvailable(c *gin.Context, s *Service) bool
+//! \brief Refuses a two-factor endpoint with 503 Service Unavailable while no MFA_SECRET_KEY is configured.
+//! \param c Gin context.
+//! \param s Authentication service instance.
+//! \return Whether the handler may continue.
+func mfaAvailable(c *gin.Context, s *Service) bool {
+	if s.mfaKey == nil {
+		respondError(c, ErrMFAUnavailable, "")
+		return false
+	}
+	return true
+}
+
+//! \fn GetMFAHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler showing the user's two-factor authentication settings.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func GetMFAHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		status, err := s.MFAStatus(userID.(int))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+//! \fn EnrollTOTPHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler generating a TOTP secret to be confirmed with a code.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func EnrollTOTPHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mfaAvailable(c, s) {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		enrollment, err := s.EnrollTOTP(userID.(int))
+		if err != nil {
+			respondError(c, err, "Failed to enroll")
+			return
+		}
+		c.JSON(http.StatusCreated, enrollment)
+	}
+}
+
+//! \fn ConfirmTOTPHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler enabling two-factor authentication with a first code.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func ConfirmTOTPHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mfaAvailable(c, s) {
+			return
+		}
+		code, ok := bindCode(c, s)
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		codes, err := s.ConfirmTOTP(userID.(int), code)
+		if err != nil {
+			respondError(c, err, "Failed to enable two-factor authentication")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled", "recovery_codes": codes})
+	}
+}
+
+//! \fn DisableMFAHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler turning two-factor authentication off.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func DisableMFAHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mfaAvailable(c, s) {
+			return
+		}
+		code, ok := bindCode(c, s)
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		if err := s.DisableMFA(userID.(int), code); err != nil {
+			respondError(c, err, "Failed to disable two-factor authentication")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+	}
+}
+
+//! \fn RegenerateRecoveryCodesHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler replacing the user's recovery codes.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func RegenerateRecoveryCodesHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mfaAvailable(c, s) {
+			return
+		}
+		code, ok := bindCode(c, s)
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		codes, err := s.RegenerateRecoveryCodes(userID.(int), code)
+		if err != nil {
+			respondError(c, err, "Failed to regenerate recovery codes")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+//! \fn VerifyMFAHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler completing a login with the MFA token and a TOTP or recovery code.
+//! \param s Authentication service instance.
+//! \return Gin handler function.
+func VerifyMFAHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mfaAvailable(c, s) {
+			return
+		}
+		var input struct {
+			MFAToken string `json:"mfa_token" validate:"required"`
+			Code     string `json:"code" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			s.Logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&input); err != nil {
+			s.Logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := s.VerifyMFA(input.MFAToken, input.Code, requestClient(c))
+		if err != nil {
+			respondError(c, err, "Failed to log in")
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+//! \fn bindCode(c *gin.Context, s *Service) (string, bool)
+//! \brief Reads the {"code": ...} body of the two-factor settings endpoints.
+//! \param c Gin context.
+//! \param s Authentication service instance.
+//! \return Code, and false if a 400 response was written instead.
+func bindCode(c *gin.Context, s *Service) (string, bool) {
+	var input struct {
+		Code string `json:"code" validate:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		s.Logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return "", false
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(&input); err != nil {
+		s.Logger.Warn("Validation failed", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return input.Code, true
+}
+
+//! \fn respondError(c *gin.Context, err error, message string)
+//! \brief Writes the response for a failed two-factor authentication operation.
+//! \param c Gin context.
+//! \param err Error returned by the service.
+//! \param message Message used for unexpected errors.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrInvalidMFAToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidMFACode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrMFALocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrMFAAlreadyEnabled), errors.Is(err, ErrMFANotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrMFAUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,513 @@
+package auth
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"task-tracker/internal/events"
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	maxMFAAttempts    = 5
+	maxMFAFailures    = 10
+	mfaLockout        = 15 * time.Minute
+	recoveryCodeCount = 10
+)
+
+//! \var ErrMFAAlreadyEnabled
+//! \brief Returned when enrolling or confirming while two-factor authentication is already on.
+var ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+//! \var ErrMFANotEnabled
+//! \brief Returned when confirming without an enrollment, or changing settings while 2FA is off.
+var ErrMFANotEnabled = errors.New("two-factor authentication is not enabled")
+
+//! \var ErrInvalidMFACode
+//! \brief Returned when a TOTP or recovery code is wrong or was already used.
+var ErrInvalidMFACode = errors.New("invalid authentication code")
+
+//! \var ErrMFALocked
+//! \brief Returned while codes are refused after too many wrong ones, whichever login they were entered for.
+var ErrMFALocked = errors.New("too many wrong authentication codes, try again later")
+
+//! \var ErrMFAUnavailable
+//! \brief Returned by the two-factor endpoints when no MFA_SECRET_KEY is configured to encrypt TOTP secrets.
+var ErrMFAUnavailable = errors.New("two-factor authentication is not available on this server")
+
+//! \var ErrInvalidMFAToken
+//! \brief Returned when an MFA challenge token is unknown, expired or out of attempts.
+var ErrInvalidMFAToken = errors.New("invalid or expired MFA token")
+
+//! \var recoveryEncoding
+//! \brief Lower-case base32 without padding, unambiguous to read and type.
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+//! \struct mfaState
+//! \brief A user's TOTP row, locked for the duration of a transaction.
+type mfaState struct {
+	secret      []byte
+	enabled     bool
+	lastStep    int64
+	failures    int
+	lockedUntil *time.Time
+}
+
+//! \fn loadMFA(tx *sql.Tx, userID int) (*mfaState, error)
+//! \brief Locks and decrypts a user's TOTP settings.
+//! \param tx Open transaction.
+//! \param userID ID of the user.
+//! \return Settings and error (if any); sql.ErrNoRows if the user never enrolled.
+func (s *Service) loadMFA(tx *sql.Tx, userID int) (*mfaState, error) {
+	var state mfaState
+	var sealed []byte
+	var enabledAt *time.Time
+	query := `SELECT secret, enabled_at, last_step, failed_attempts, locked_until FROM user_mfa
+              WHERE user_id = $1 FOR UPDATE`
+	err := tx.QueryRow(query, userID).Scan(&sealed, &enabledAt, &state.lastStep, &state.failures, &state.lockedUntil)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			s.Logger.Error("Failed to fetch MFA settings", zap.Error(err))
+		}
+		return nil, err
+	}
+	secret, err := s.openSecret(sealed, userID)
+	if err != nil {
+		s.Logger.Error("Failed to decrypt TOTP secret", zap.Int("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	state.secret, state.enabled = secret, enabledAt != nil
+	return &state, nil
+}
+
+//! \fn normalizeCode(code string) string
+//! \brief Strips the separators users type into codes.
+//! \param code Code as entered.
+//! \return Lower-case code without spaces or dashes.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+//! \fn checkCode(tx *sql.Tx, userID int, state *mfaState, code string, allowRecovery bool) error
+//! \brief Accepts a TOTP code, or a recovery code if allowed, and burns it so it cannot be replayed.
+//!        Wrong codes are counted per user across logins; after maxMFAFailures of them every code
+//!        is refused for mfaLockout. Callers commit on ErrInvalidMFACode to keep the count.
+//! \param tx Open transaction holding the lock on the user's settings.
+//! \param userID ID of the user.
+//! \param state User's TOTP settings.
+//! \param code Code entered by the user.
+//! \param allowRecovery Whether a recovery code may stand in for a TOTP code.
+//! \return Error (if any); ErrInvalidMFACode if the code is not accepted, ErrMFALocked while locked out.
+func (s *Service) checkCode(tx *sql.Tx, userID int, state *mfaState, code string, allowRecovery bool) error {
+	now := time.Now().UTC()
+	if state.lockedUntil != nil && now.Before(*state.lockedUntil) {
+		s.Logger.Warn("MFA code during lockout", zap.Int("user_id", userID))
+		return ErrMFALocked
+	}
+
+	ok, err := s.useCode(tx, userID, state, normalizeCode(code), allowRecovery, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.recordFailure(tx, userID, state, now)
+	}
+	if state.failures > 0 || state.lockedUntil != nil {
+		query := `UPDATE user_mfa SET failed_attempts = 0, locked_until = NULL WHERE user_id = $1`
+		if _, err := tx.Exec(query, userID); err != nil {
+			s.Logger.Error("Failed to reset MFA failures", zap.Error(err))
+			return err
+		}
+		state.failures, state.lockedUntil = 0, nil
+	}
+	return nil
+}
+
+//! \fn useCode(tx *sql.Tx, userID int, state *mfaState, code string, allowRecovery bool, now time.Time) (bool, error)
+//! \brief Matches a normalized code against the TOTP secret and, if allowed, the unused recovery codes.
+//! \param tx Open transaction holding the lock on the user's settings.
+//! \param userID ID of the user.
+//! \param state User's TOTP settings.
+//! \param code Normalized code.
+//! \param allowRecovery Whether a recovery code may stand in for a TOTP code.
+//! \param now Current time in UTC.
+//! \return Whether the code was accepted and burned, and error (if any).
+func (s *Service) useCode(tx *sql.Tx, userID int, state *mfaState, code string, allowRecovery bool, now time.Time) (bool, error) {
+	if step, ok := checkTOTP(state.secret, code, now, state.lastStep); ok {
+		if _, err := tx.Exec(`UPDATE user_mfa SET last_step = $2 WHERE user_id = $1`, userID, step); err != nil {
+			s.Logger.Error("Failed to record TOTP use", zap.Error(err))
+			return false, err
+		}
+		state.lastStep = step
+		return true, nil
+	}
+	if !allowRecovery || len(code) == totpDigits {
+		return false, nil
+	}
+
+	query := `UPDATE mfa_recovery_codes SET used_at = $3
+              WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL RETURNING id`
+	var id int
+	err := tx.QueryRow(query, userID, hashToken(code), now).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		s.Logger.Error("Failed to use recovery code", zap.Error(err))
+		return false, err
+	}
+	s.Logger.Info("Recovery code used", zap.Int("user_id", userID))
+	return true, nil
+}
+
+//! \fn recordFailure(tx *sql.Tx, userID int, state *mfaState, now time.Time) error
+//! \brief Counts a wrong code and starts a lockout once maxMFAFailures are reached.
+//! \param tx Open transaction holding the lock on the user's settings.
+//! \param userID ID of the user.
+//! \param state User's TOTP settings.
+//! \param now Current time in UTC.
+//! \return ErrInvalidMFACode, or another error if the count could not be stored.
+func (s *Service) recordFailure(tx *sql.Tx, userID int, state *mfaState, now time.Time) error {
+	failures := state.failures + 1
+	var lockedUntil *time.Time
+	if failures >= maxMFAFailures {
+		until := now.Add(mfaLockout)
+		failures, lockedUntil = 0, &until
+		s.Logger.Warn("MFA locked after repeated wrong codes", zap.Int("user_id", userID))
+	}
+	query := `UPDATE user_mfa SET failed_attempts = $2, locked_until = $3 WHERE user_id = $1`
+	if _, err := tx.Exec(query, userID, failures, lockedUntil); err != nil {
+		s.Logger.Error("Failed to record MFA failure", zap.Error(err))
+		return err
+	}
+	state.failures, state.lockedUntil = failures, lockedUntil
+	return ErrInvalidMFACode
+}
+
+//! \fn keepFailure(tx *sql.Tx, err error) error
+//! \brief Commits the failure checkCode recorded before a wrong code is reported, so that the
+//!        caller's rollback does not erase it.
+//! \param tx Open transaction.
+//! \param err Error returned by checkCode.
+//! \return The same error, or the commit error.
+func (s *Service) keepFailure(tx *sql.Tx, err error) error {
+	if err != ErrInvalidMFACode {
+		return err
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		s.Logger.Error("Failed to commit transaction", zap.Error(commitErr))
+		return commitErr
+	}
+	return err
+}
+
+//! \fn replaceRecoveryCodes(tx *sql.Tx, userID int) ([]string, error)
+//! \brief Generates a fresh set of recovery codes, invalidating the previous ones.
+//! \param tx Open transaction.
+//! \param userID ID of the user.
+//! \return Codes formatted as xxxxx-xxxxx, and error (if any).
+func (s *Service) replaceRecoveryCodes(tx *sql.Tx, userID int) ([]string, error) {
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		s.Logger.Error("Failed to delete recovery codes", zap.Error(err))
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := recoveryEncoding.EncodeToString(buf)[:10]
+		query := `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.Exec(query, userID, hashToken(code)); err != nil {
+			s.Logger.Error("Failed to store recovery code", zap.Error(err))
+			return nil, err
+		}
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+//! \fn mfaEnabled(userID int) (bool, error)
+//! \brief Checks whether logins of a user need a second factor.
+//! \param userID ID of the user.
+//! \return Whether 2FA is enabled and error (if any).
+func (s *Service) mfaEnabled(userID int) (bool, error) {
+	var enabled bool
+	query := `SELECT EXISTS (SELECT 1 FROM user_mfa WHERE user_id = $1 AND enabled_at IS NOT NULL)`
+	if err := s.db.QueryRow(query, userID).Scan(&enabled); err != nil {
+		s.Logger.Error("Failed to check MFA settings", zap.Error(err))
+		return false, err
+	}
+	return enabled, nil
+}
+
+//! \fn MFAStatus(userID int) (*models.MFAStatus, error)
+//! \brief Retrieves a user's two-factor authentication settings.
+//! \param userID ID of the user.
+//! \return Status and error (if any).
+func (s *Service) MFAStatus(userID int) (*models.MFAStatus, error) {
+	var status models.MFAStatus
+	query := `SELECT m.enabled_at,
+                     (SELECT COUNT(*) FROM mfa_recovery_codes c WHERE c.user_id = u.id AND c.used_at IS NULL)
+              FROM users u LEFT JOIN user_mfa m ON m.user_id = u.id WHERE u.id = $1`
+	if err := s.db.QueryRow(query, userID).Scan(&status.EnabledAt, &status.RecoveryCodesLeft); err != nil {
+		s.Logger.Error("Failed to fetch MFA settings", zap.Error(err))
+		return nil, err
+	}
+	status.Enabled = status.EnabledAt != nil
+	return &status, nil
+}
+
+//! \fn EnrollTOTP(userID int) (*models.TOTPEnrollment, error)
+//! \brief Generates a TOTP secret for the user to add to an authenticator app. It only takes
+//!        effect once confirmed with a code; enrolling again replaces an unconfirmed secret.
+//! \param userID ID of the user.
+//! \return Secret and otpauth URI, and error (if any); ErrMFAAlreadyEnabled if 2FA is on.
+func (s *Service) EnrollTOTP(userID int) (*models.TOTPEnrollment, error) {
+	var username string
+	if err := s.db.QueryRow(`SELECT username FROM users WHERE id = $1`, userID).Scan(&username); err != nil {
+		s.Logger.Error("Failed to fetch username", zap.Error(err))
+		return nil, err
+	}
+
+	secret := make([]byte, totpSecret)
+	if _, err := rand.Read(secret); err != nil {
+		s.Logger.Error("Failed to generate TOTP secret", zap.Error(err))
+		return nil, err
+	}
+	sealed, err := s.sealSecret(secret, userID)
+	if err != nil {
+		s.Logger.Error("Failed to encrypt TOTP secret", zap.Error(err))
+		return nil, err
+	}
+
+	query := `INSERT INTO user_mfa (user_id, secret) VALUES ($1, $2)
+              ON CONFLICT (user_id) DO UPDATE
+              SET secret = EXCLUDED.secret, last_step = 0, created_at = CURRENT_TIMESTAMP
+              WHERE user_mfa.enabled_at IS NULL
+              RETURNING user_id`
+	var id int
+	err = s.db.QueryRow(query, userID, sealed).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err != nil {
+		s.Logger.Error("Failed to store TOTP secret", zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Info("TOTP enrollment started", zap.Int("user_id", userID))
+	return &models.TOTPEnrollment{
+		Secret: secretEncoding.EncodeToString(secret),
+		URI:    otpauthURI(username, secret),
+	}, nil
+}
+
+//! \fn ConfirmTOTP(userID int, code string) ([]string, error)
+//! \brief Turns on two-factor authentication once the user proves their app produces valid codes.
+//! \param userID ID of the user.
+//! \param code Current code from the authenticator app.
+//! \return Recovery codes, shown only this once, and error (if any).
+func (s *Service) ConfirmTOTP(userID int, code string) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.Logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	state, err := s.loadMFA(tx, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMFANotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if state.enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err := s.checkCode(tx, userID, state, code, false); err != nil {
+		return nil, s.keepFailure(tx, err)
+	}
+
+	if _, err := tx.Exec(`UPDATE user_mfa SET enabled_at = $2 WHERE user_id = $1`, userID, time.Now().UTC()); err != nil {
+		s.Logger.Error("Failed to enable MFA", zap.Error(err))
+		return nil, err
+	}
+	codes, err := s.replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Info("Two-factor authentication enabled", zap.Int("user_id", userID))
+	s.publish(events.MFAEnabled, userID)
+	return codes, nil
+}
+
+//! \fn DisableMFA(userID int, code string) error
+//! \brief Turns off two-factor authentication and deletes the secret and recovery codes.
+//! \param userID ID of the user.
+//! \param code Current TOTP code or an unused recovery code.
+//! \return Error (if any).
+func (s *Service) DisableMFA(userID int, code string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.Logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	state, err := s.loadMFA(tx, userID)
+	if err == sql.ErrNoRows || (err == nil && !state.enabled) {
+		return ErrMFANotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.checkCode(tx, userID, state, code, true); err != nil {
+		return s.keepFailure(tx, err)
+	}
+
+	for _, query := range []string{
+		`DELETE FROM mfa_challenges WHERE user_id = $1`,
+		`DELETE FROM mfa_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_mfa WHERE user_id = $1`,
+	} {
+		if _, err := tx.Exec(query, userID); err != nil {
+			s.Logger.Error("Failed to disable MFA", zap.Error(err))
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	s.Logger.Info("Two-factor authentication disabled", zap.Int("user_id", userID))
+	s.publish(events.MFADisabled, userID)
+	return nil
+}
+
+//! \fn RegenerateRecoveryCodes(userID int, code string) ([]string, error)
+//! \brief Replaces the user's recovery codes, e.g. when running out of them.
+//! \param userID ID of the user.
+//! \param code Current TOTP code.
+//! \return New recovery codes and error (if any).
+func (s *Service) RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.Logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	state, err := s.loadMFA(tx, userID)
+	if err == sql.ErrNoRows || (err == nil && !state.enabled) {
+		return nil, ErrMFANotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCode(tx, userID, state, code, false); err != nil {
+		return nil, s.keepFailure(tx, err)
+	}
+	codes, err := s.replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.Logger.Info("Recovery codes regenerated", zap.Int("user_id", userID))
+	return codes, nil
+}
+
+//! \fn startChallenge(userID int) (string, error)
+//! \brief Issues the short-lived token a login with 2FA exchanges, together with a code, for a session.
+//! \param userID ID of the user who passed the password check.
+//! \return Challenge token and error (if any).
+func (s *Service) startChallenge(userID int) (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	query := `INSERT INTO mfa_challenges (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`
+	if _, err := s.db.Exec(query, hashToken(token), userID, time.Now().UTC().Add(mfaChallengeTTL)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+//! \fn VerifyMFA(challengeToken, code string, client Client) (*LoginResult, error)
+//! \brief Completes a login with 2FA. Each challenge allows a few attempts, and wrong codes also
+//!        count towards the user's lockout; the challenge is consumed by the first correct code.
+//! \param challengeToken Token returned by Login.
+//! \param code TOTP code or an unused recovery code.
+//! \param client Device the user logs in from.
+//! \return Access and refresh tokens, and error (if any).
+func (s *Service) VerifyMFA(challengeToken, code string, client Client) (*LoginResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.Logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var userID, attempts int
+	query := `UPDATE mfa_challenges SET attempts = attempts + 1
+              WHERE token_hash = $1 AND expires_at > $2 AND attempts < $3 RETURNING user_id, attempts`
+	hash := hashToken(challengeToken)
+	err = tx.QueryRow(query, hash, time.Now().UTC(), maxMFAAttempts).Scan(&userID, &attempts)
+	if err == sql.ErrNoRows {
+		s.Logger.Warn("Invalid MFA challenge token")
+		return nil, ErrInvalidMFAToken
+	}
+	if err != nil {
+		s.Logger.Error("Failed to fetch MFA challenge", zap.Error(err))
+		return nil, err
+	}
+
+	state, err := s.loadMFA(tx, userID)
+	if err == sql.ErrNoRows || (err == nil && !state.enabled) {
+		// 2FA was turned off meanwhile; the login has to start over.
+		return nil, ErrInvalidMFAToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCode(tx, userID, state, code, true); err != nil {
+		if err == ErrInvalidMFACode {
+			s.Logger.Warn("Invalid MFA code", zap.Int("user_id", userID), zap.Int("attempt", attempts))
+		}
+		return nil, s.keepFailure(tx, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM mfa_challenges WHERE token_hash = $1`, hash); err != nil {
+		s.Logger.Error("Failed to delete MFA challenge", zap.Error(err))
+		return nil, err
+	}
+
+	var username string
+	if err := tx.QueryRow(`SELECT username FROM users WHERE id = $1`, userID).Scan(&username); err != nil {
+		s.Logger.Error("Failed to fetch username", zap.Error(err))
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+	return s.startSession(userID, username, client)
+}
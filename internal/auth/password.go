@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"task-tracker/internal/events"
+	"task-tracker/internal/mail"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//! \const resetTokenTTL
+//! \brief How long a password reset link stays valid.
+const resetTokenTTL = time.Hour
+
+//! \const mailTimeout
+//! \brief Time allowed for handing an email to the mail sender.
+const mailTimeout = 30 * time.Second
+
+//! \var ErrInvalidResetToken
+//! \brief Returned when a password reset token is unknown, expired or already used.
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+//! \fn newToken() (string, error)
+//! \brief Generates a random URL-safe token.
+//! \return Token and error (if any).
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+//! \fn sendMail(msg mail.Message)
+//! \brief Sends an email in the background so that response times do not depend on
+//!        whether, or how quickly, a message was sent.
+//! \param msg Message to send.
+func (s *Service) sendMail(msg mail.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			s.Logger.Error("Failed to send email", zap.String("subject", msg.Subject), zap.Error(err))
+		}
+	}()
+}
+
+//! \fn ForgotPassword(email string)
+//! \brief Emails a password reset link if an account uses the address. The lookup, token and
+//!        email are handled in the background, so known and unknown addresses take the same
+//!        time to answer and callers cannot tell whether an account exists.
+//! \param email Email address entered by the user.
+func (s *Service) ForgotPassword(email string) {
+	go func() {
+		if err := s.sendResetLink(strings.TrimSpace(email)); err != nil {
+			s.Logger.Error("Failed to send password reset link", zap.Error(err))
+		}
+	}()
+}
+
+//! \fn sendResetLink(email string) error
+//! \brief Issues a reset token for the account using an address and emails the link. Addresses
+//!        are unique regardless of case, so at most one account matches.
+//! \param email Email address entered by the user.
+//! \return Error (if any); unknown addresses are not an error.
+func (s *Service) sendResetLink(email string) error {
+	var userID int
+	var username, address string
+	query := `SELECT id, username, email FROM users WHERE LOWER(email) = LOWER($1)`
+	err := s.db.QueryRow(query, email).Scan(&userID, &username, &address)
+	if err == sql.ErrNoRows {
+		s.Logger.Info("Password reset requested for unknown email")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Only the most recent link works.
+	now := time.Now().UTC()
+	if _, err := tx.Exec(`DELETE FROM password_resets WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	query = `INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(query, userID, hashToken(token), now.Add(resetTokenTTL)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	link := s.appURL + "/?reset_token=" + url.QueryEscape(token)
+	s.sendMail(mail.Message{
+		To:      address,
+		Subject: "Reset your Task Tracker password",
+		Body: fmt.Sprintf("Hello %s,\n\nSomeone asked to reset the password of your account. "+
+			"To choose a new password, open this link within %d minutes:\n\n%s\n\n"+
+			"If it was not you, ignore this email; your password stays unchanged.\n",
+			username, int(resetTokenTTL.Minutes()), link),
+	})
+	s.Logger.Info("Password reset requested", zap.Int("user_id", userID))
+	return nil
+}
+
+//! \fn ResetPassword(token, password string) error
+//! \brief Sets a new password with a reset token. The token is single-use and every session of
+//!        the user is revoked, so whoever knew the old password is logged out.
+//! \param token Reset token from the emailed link.
+//! \param password New password.
+//! \return Error (if any); ErrInvalidResetToken if the token cannot be used.
+func (s *Service) ResetPassword(token, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		s.Logger.Error("Failed to hash password", zap.Error(err))
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.Logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	now := time.Now().UTC()
+	query := `UPDATE password_resets SET used_at = $2
+              WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2 RETURNING user_id`
+	err = tx.QueryRow(query, hashToken(token), now).Scan(&userID)
+	if err == sql.ErrNoRows {
+		s.Logger.Warn("Invalid password reset token")
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		s.Logger.Error("Failed to fetch reset token", zap.Error(err))
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET password_hash = $2 WHERE id = $1`, userID, string(hash)); err != nil {
+		s.Logger.Error("Failed to update password", zap.Error(err))
+		return err
+	}
+	ids, _, err := s.revokeSessions(tx, revokeAllQuery, userID, now)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	s.announceRevoked(userID, ids)
+	s.Logger.Info("Password reset", zap.Int("user_id", userID))
+	s.publish(events.PasswordReset, userID)
+	return nil
+}
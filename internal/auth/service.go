@@ -1,12 +1,15 @@
 package auth
 
 import (
+	"context"
 	"database/sql"
+	"strings"
 	"time"
 
+	"task-tracker/internal/events"
+	"task-tracker/internal/mail"
 	"task-tracker/internal/models"
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -14,27 +17,54 @@ import (
 //! \struct Service
 //! \brief Encapsulates authentication business logic.
 type Service struct {
-	db     *sql.DB
-	secret []byte
-	Logger *zap.Logger
+	db       *sql.DB
+	bus      events.Bus
+	mailer   mail.Sender
+	secret   []byte
+	appURL   string
+	verified bool
+	mfaKey   []byte
+	sessions sessionCache
+	Logger   *zap.Logger
 }
 
-//! \fn NewService(db *sql.DB, secret string, logger *zap.Logger) *Service
+//! \fn NewService(db *sql.DB, bus events.Bus, mailer mail.Sender, secret, appURL string, requireVerified bool, mfaSecretKey string, logger *zap.Logger) *Service
 //! \brief Initializes a new authentication service.
 //! \param db Database connection.
+//! \param bus Event bus receiving account events.
+//! \param mailer Sender of account emails.
 //! \param secret JWT secret key.
+//! \param appURL Base URL of the frontend, used in emailed links.
+//! \param requireVerified Whether users must verify their email address before logging in.
+//! \param mfaSecretKey Key encrypting TOTP secrets at rest; empty disables the two-factor endpoints.
 //! \param logger Logger instance.
 //! \return Pointer to initialized Service.
-func NewService(db *sql.DB, secret string, logger *zap.Logger) *Service {
+func NewService(db *sql.DB, bus events.Bus, mailer mail.Sender, secret, appURL string, requireVerified bool,
+	mfaSecretKey string, logger *zap.Logger) *Service {
 	return &Service{
-		db:     db,
-		secret: []byte(secret),
-		Logger: logger,
+		db:       db,
+		bus:      bus,
+		mailer:   mailer,
+		secret:   []byte(secret),
+		appURL:   strings.TrimRight(appURL, "/"),
+		verified: requireVerified,
+		mfaKey:   mfaKey(mfaSecretKey),
+		Logger:   logger,
+	}
+}
+
+//! \fn publish(eventType string, userID int)
+//! \brief Publishes an account event. Delivery is best-effort and never fails the request.
+//! \param eventType Type of the event.
+//! \param userID ID of the user concerned.
+func (s *Service) publish(eventType string, userID int) {
+	if err := s.bus.Publish(context.Background(), events.Event{Type: eventType, UserID: userID}); err != nil {
+		s.Logger.Warn("Failed to publish event", zap.String("type", eventType), zap.Error(err))
 	}
 }
 
 //! \fn Register(user *models.User) (int, error)
-//! \brief Creates a new user in the database.
+//! \brief Creates a new user in the database and emails a link to verify the address.
 //! \param user User data to register.
 //! \return User ID and error (if any).
 func (s *Service) Register(user *models.User) (int, error) {
@@ -52,74 +82,79 @@ func (s *Service) Register(user *models.User) (int, error) {
 	}
 
 	s.Logger.Info("User registered", zap.Int("user_id", userID))
+	s.publish(events.UserRegistered, userID)
+
+	// The account exists either way; a lost link can be requested again.
+	s.sendVerification(userID, user.Username, user.Email)
 	return userID, nil
 }
 
-//! \fn Login(username, password string) (string, string, error)
-//! \brief Authenticates a user and generates tokens.
+//! \fn Login(username, password string, client Client) (*LoginResult, error)
+//! \brief Authenticates a user and starts a session. Users with two-factor authentication get
+//!        an MFA challenge token instead, to be exchanged at VerifyMFA together with a code.
 //! \param username User's username.
 //! \param password User's password.
-//! \return Access token, refresh token, and error (if any).
-func (s *Service) Login(username, password string) (string, string, error) {
+//! \param client Device the user logs in from.
+//! \return Tokens or MFA challenge, and error (if any); ErrEmailNotVerified if verification
+//!         is required and the password was right.
+func (s *Service) Login(username, password string, client Client) (*LoginResult, error) {
 	var user models.User
-	query := `SELECT id, username, password_hash FROM users WHERE username = $1`
-	err := s.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.PasswordHash)
+	query := `SELECT id, username, password_hash, email_verified_at FROM users WHERE username = $1`
+	err := s.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.EmailVerifiedAt)
 	if err != nil {
 		s.Logger.Warn("User not found", zap.Error(err))
-		return "", "", err
+		return nil, err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
 		s.Logger.Warn("Invalid password", zap.Error(err))
-		return "", "", err
+		return nil, err
 	}
 
-	accessToken, err := s.generateAccessToken(user.ID, user.Username)
-	if err != nil {
-		s.Logger.Error("Failed to generate access token", zap.Error(err))
-		return "", "", err
+	if s.verified && user.EmailVerifiedAt == nil {
+		s.Logger.Warn("Login with unverified email", zap.Int("user_id", user.ID))
+		return nil, ErrEmailNotVerified
 	}
 
-	refreshToken, err := s.generateRefreshToken(user.ID)
+	mfa, err := s.mfaEnabled(user.ID)
 	if err != nil {
-		s.Logger.Error("Failed to generate refresh token", zap.Error(err))
-		return "", "", err
+		return nil, err
+	}
+	if mfa {
+		token, err := s.startChallenge(user.ID)
+		if err != nil {
+			s.Logger.Error("Failed to start MFA challenge", zap.Error(err))
+			return nil, err
+		}
+		s.Logger.Info("Password accepted, awaiting second factor", zap.Int("user_id", user.ID))
+		return &LoginResult{MFARequired: true, MFAToken: token}, nil
 	}
 
-	s.Logger.Info("User logged in", zap.Int("user_id", user.ID))
-	return accessToken, refreshToken, nil
+	return s.startSession(user.ID, user.Username, client)
 }
 
-//! \fn Refresh(refreshToken string) (string, error)
-//! \brief Generates a new access token using a refresh token.
-//! \param refreshToken Refresh token to validate.
-//! \return New access token and error (if any).
-func (s *Service) Refresh(refreshToken string) (string, error) {
-	var userID int
-	var expiresAt time.Time
-	query := `SELECT user_id, expires_at FROM refresh_tokens WHERE token = $1`
-	err := s.db.QueryRow(query, refreshToken).Scan(&userID, &expiresAt)
-	if err != nil || expiresAt.Before(time.Now()) {
-		s.Logger.Warn("Invalid or expired refresh token", zap.Error(err))
-		return "", err
-	}
-
-	var username string
-	query = `SELECT username FROM users WHERE id = $1`
-	err = s.db.QueryRow(query, userID).Scan(&username)
+//! \fn startSession(userID int, username string, client Client) (*LoginResult, error)
+//! \brief Starts a session for an authenticated user and issues its tokens.
+//! \param userID ID of the user.
+//! \param username User's username.
+//! \param client Device the user logs in from.
+//! \return Access and refresh tokens, and error (if any).
+func (s *Service) startSession(userID int, username string, client Client) (*LoginResult, error) {
+	sessionID, refreshToken, err := s.startFamily(userID, client)
 	if err != nil {
-		s.Logger.Error("Failed to fetch username", zap.Error(err))
-		return "", err
+		s.Logger.Error("Failed to generate refresh token", zap.Error(err))
+		return nil, err
 	}
 
-	accessToken, err := s.generateAccessToken(userID, username)
+	accessToken, err := s.generateAccessToken(userID, username, sessionID)
 	if err != nil {
 		s.Logger.Error("Failed to generate access token", zap.Error(err))
-		return "", err
+		return nil, err
 	}
 
-	s.Logger.Info("Token refreshed", zap.Int("user_id", userID))
-	return accessToken, nil
+	s.Logger.Info("User logged in", zap.Int("user_id", userID))
+	s.publish(events.UserLoggedIn, userID)
+	return &LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
 //! \fn VerifyToken(tokenString string) (*TokenClaims, error)
@@ -144,15 +179,17 @@ func (s *Service) VerifyToken(tokenString string) (*TokenClaims, error) {
 	return claims, nil
 }
 
-//! \fn generateAccessToken(userID int, username string) (string, error)
+//! \fn generateAccessToken(userID int, username, sessionID string) (string, error)
 //! \brief Generates a JWT access token.
 //! \param userID User ID.
 //! \param username User's username.
+//! \param sessionID Session the token belongs to.
 //! \return Signed access token and error (if any).
-func (s *Service) generateAccessToken(userID int, username string) (string, error) {
+func (s *Service) generateAccessToken(userID int, username, sessionID string) (string, error) {
 	claims := &TokenClaims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -161,18 +198,3 @@ func (s *Service) generateAccessToken(userID int, username string) (string, erro
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(s.secret)
 }
-
-//! \fn generateRefreshToken(userID int) (string, error)
-//! \brief Generates and stores a refresh token.
-//! \param userID User ID.
-//! \return Refresh token and error (if any).
-func (s *Service) generateRefreshToken(userID int) (string, error) {
-	token := uuid.New().String()
-	expiresAt := time.Now().Add(7 * 24 * time.Hour)
-	query := `INSERT INTO refresh_tokens (user_id, token, expires_at) VALUES ($1, $2, $3)`
-	_, err := s.db.Exec(query, userID, token, expiresAt)
-	if err != nil {
-		return "", err
-	}
-	return token, nil
-}
\ No newline at end of file
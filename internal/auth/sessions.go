@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"task-tracker/internal/events"
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \const sessionCacheTTL
+//! \brief How long the state of a session is cached. Revocations reach other instances over
+//!        the event bus; the TTL only bounds staleness if such an event is lost.
+const sessionCacheTTL = time.Minute
+
+//! \const maxUserAgent
+//! \brief Longest user agent stored for a session.
+const maxUserAgent = 512
+
+//! \struct Client
+//! \brief Describes the device a session was started or used from.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+//! \struct sessionEntry
+//! \brief Cached state of one session.
+type sessionEntry struct {
+	active  bool
+	expires time.Time
+}
+
+//! \struct sessionCache
+//! \brief Remembers whether sessions are active so that access tokens can be checked
+//!        without a query per request.
+type sessionCache struct {
+	mu      sync.Mutex
+	entries map[string]sessionEntry
+}
+
+//! \fn get(id string, now time.Time) (bool, bool)
+//! \brief Looks up a session.
+//! \param id Session ID.
+//! \param now Current time.
+//! \return Whether the session is active, and whether the entry was cached and fresh.
+func (c *sessionCache) get(id string, now time.Time) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[id]
+	if !ok || now.After(entry.expires) {
+		return false, false
+	}
+	return entry.active, true
+}
+
+//! \fn set(id string, active bool, now time.Time)
+//! \brief Caches the state of a session, evicting stale entries as the cache grows.
+//! \param id Session ID.
+//! \param active Whether the session is active.
+//! \param now Current time.
+func (c *sessionCache) set(id string, active bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]sessionEntry{}
+	}
+	if len(c.entries) >= 10000 {
+		for key, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	c.entries[id] = sessionEntry{active: active, expires: now.Add(sessionCacheTTL)}
+}
+
+//! \fn reset()
+//! \brief Forgets every cached session.
+func (c *sessionCache) reset() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
+
+//! \struct revokedSessions
+//! \brief Data of a SessionRevoked event.
+type revokedSessions struct {
+	SessionIDs []string `json:"session_ids"`
+}
+
+//! \fn clientAgent(client Client) string
+//! \brief Truncates a client's user agent for storage.
+//! \param client Client description.
+//! \return User agent.
+func clientAgent(client Client) string {
+	if len(client.UserAgent) > maxUserAgent {
+		return client.UserAgent[:maxUserAgent]
+	}
+	return client.UserAgent
+}
+
+//! \fn SessionActive(sessionID string) (bool, error)
+//! \brief Checks whether access tokens of a session are still accepted.
+//! \param sessionID Session ID from the token's sid claim.
+//! \return Whether the session is active and error (if any).
+func (s *Service) SessionActive(sessionID string) (bool, error) {
+	now := time.Now()
+	if active, ok := s.sessions.get(sessionID, now); ok {
+		return active, nil
+	}
+
+	// Families are only deleted once all their tokens expired, long after any access token.
+	var active bool
+	query := `SELECT revoked_at IS NULL FROM token_families WHERE id = $1`
+	err := s.db.QueryRow(query, sessionID).Scan(&active)
+	if err != nil && err != sql.ErrNoRows {
+		s.Logger.Error("Failed to check session", zap.Error(err))
+		return false, err
+	}
+	s.sessions.set(sessionID, active, now)
+	return active, nil
+}
+
+//! \fn ListSessions(userID int, currentID string) ([]models.Session, error)
+//! \brief Lists a user's active sessions, most recently used first.
+//! \param userID ID of the user.
+//! \param currentID Session of the request, flagged as current.
+//! \return Sessions and error (if any).
+func (s *Service) ListSessions(userID int, currentID string) ([]models.Session, error) {
+	query := `SELECT id, COALESCE(user_agent, ''), COALESCE(ip, ''), created_at, COALESCE(last_used_at, created_at)
+              FROM token_families WHERE user_id = $1 AND revoked_at IS NULL
+              AND EXISTS (SELECT 1 FROM refresh_tokens t WHERE t.family_id = token_families.id
+                          AND t.used_at IS NULL AND t.expires_at > $2)
+              ORDER BY COALESCE(last_used_at, created_at) DESC`
+	rows, err := s.db.Query(query, userID, time.Now().UTC())
+	if err != nil {
+		s.Logger.Error("Failed to fetch sessions", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.UserAgent, &session.IP, &session.CreatedAt,
+			&session.LastUsedAt); err != nil {
+			s.Logger.Error("Failed to scan session", zap.Error(err))
+			return nil, err
+		}
+		session.Current = session.ID == currentID
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		s.Logger.Error("Failed to iterate sessions", zap.Error(err))
+		return nil, err
+	}
+	return sessions, nil
+}
+
+//! \interface querier
+//! \brief Common interface of *sql.DB and *sql.Tx used for multi-row queries.
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+//! \const revokeAllQuery
+//! \brief Revokes every active session of the user given as $1 at the time given as $2.
+const revokeAllQuery = `UPDATE token_families SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL
+                        RETURNING id, user_id`
+
+//! \fn revokeSessions(q querier, query string, args ...interface{}) ([]string, int, error)
+//! \brief Revokes the token families selected by a query. Once the change is committed the
+//!         caller announces the revocation with announceRevoked.
+//! \param q Database connection or open transaction.
+//! \param query UPDATE statement revoking families and returning their IDs and user.
+//! \param args Query arguments.
+//! \return IDs of the revoked sessions, their user, and error (if any).
+func (s *Service) revokeSessions(q querier, query string, args ...interface{}) ([]string, int, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		s.Logger.Error("Failed to revoke sessions", zap.Error(err))
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	var userID int
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id, &userID); err != nil {
+			s.Logger.Error("Failed to scan session", zap.Error(err))
+			return nil, 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		s.Logger.Error("Failed to revoke sessions", zap.Error(err))
+		return nil, 0, err
+	}
+	return ids, userID, nil
+}
+
+//! \fn announceRevoked(userID int, ids []string)
+//! \brief Marks sessions as revoked in the local cache and tells the other instances.
+//! \param userID ID of the user.
+//! \param ids IDs of the revoked sessions.
+func (s *Service) announceRevoked(userID int, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, id := range ids {
+		s.sessions.set(id, false, now)
+	}
+	data, _ := json.Marshal(revokedSessions{SessionIDs: ids})
+	ev := events.Event{Type: events.SessionRevoked, UserID: userID, Data: data}
+	if err := s.bus.Publish(context.Background(), ev); err != nil {
+		s.Logger.Warn("Failed to publish event", zap.String("type", ev.Type), zap.Error(err))
+	}
+	s.Logger.Info("Sessions revoked", zap.Int("user_id", userID), zap.Strings("session_ids", ids))
+}
+
+//! \fn Logout(refreshToken string) error
+//! \brief Ends the session a refresh token belongs to.
+//! \param refreshToken Refresh token presented by the client.
+//! \return Error (if any); ErrInvalidRefreshToken if the token is unknown or its session already ended.
+func (s *Service) Logout(refreshToken string) error {
+	query := `UPDATE token_families f SET revoked_at = $2 FROM refresh_tokens t
+              WHERE t.family_id = f.id AND t.token = $1 AND f.revoked_at IS NULL
+              RETURNING f.id, f.user_id`
+	ids, userID, err := s.revokeSessions(s.db, query, hashToken(refreshToken), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return ErrInvalidRefreshToken
+	}
+	s.announceRevoked(userID, ids)
+	return nil
+}
+
+//! \fn RevokeSession(userID int, sessionID string) error
+//! \brief Ends one of a user's sessions, e.g. a lost device.
+//! \param userID ID of the user.
+//! \param sessionID ID of the session.
+//! \return Error (if any); sql.ErrNoRows if the user has no such active session.
+func (s *Service) RevokeSession(userID int, sessionID string) error {
+	query := `UPDATE token_families SET revoked_at = $3
+              WHERE id::text = $2 AND user_id = $1 AND revoked_at IS NULL
+              RETURNING id, user_id`
+	ids, _, err := s.revokeSessions(s.db, query, userID, sessionID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return sql.ErrNoRows
+	}
+	s.announceRevoked(userID, ids)
+	return nil
+}
+
+//! \fn RevokeAllSessions(userID int) (int, error)
+//! \brief Ends every session of a user ("log out everywhere"), including the current one.
+//! \param userID ID of the user.
+//! \return Number of sessions ended and error (if any).
+func (s *Service) RevokeAllSessions(userID int) (int, error) {
+	ids, _, err := s.revokeSessions(s.db, revokeAllQuery, userID, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	s.announceRevoked(userID, ids)
+	return len(ids), nil
+}
+
+//! \fn RunSessionSync(ctx context.Context)
+//! \brief Applies session revocations made on other instances to the local cache until the
+//!        context is cancelled. If revocations may have been missed the cache is cleared.
+//! \param ctx Context controlling the job's lifetime.
+func (s *Service) RunSessionSync(ctx context.Context) {
+	sub := s.bus.Subscribe()
+	defer func() { sub.Close() }()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				s.Logger.Warn("Session sync fell behind the bus; resubscribing")
+				sub = s.bus.Subscribe()
+				s.sessions.reset()
+				continue
+			}
+			switch ev.Type {
+			case events.Reconnected:
+				s.sessions.reset()
+			case events.SessionRevoked:
+				var data revokedSessions
+				if err := json.Unmarshal(ev.Data, &data); err != nil {
+					s.Logger.Warn("Invalid session event", zap.Error(err))
+					s.sessions.reset()
+					continue
+				}
+				now := time.Now()
+				for _, id := range data.SessionIDs {
+					s.sessions.set(id, false, now)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"task-tracker/internal/events"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	refreshTokenTTL = 7 * 24 * time.Hour
+	cleanupInterval = time.Hour
+)
+
+//! \var ErrInvalidRefreshToken
+//! \brief Returned when a refresh token is unknown, expired or belongs to a revoked family.
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+//! \var ErrRefreshTokenReused
+//! \brief Returned when a rotated-out refresh token is presented again; its family is revoked.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+//! \fn hashToken(token string) string
+//! \brief Hashes a refresh token; only hashes are stored.
+//! \param token Refresh token.
+//! \return Hex-encoded SHA-256 digest.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+//! \fn issueRefreshToken(tx *sql.Tx, userID int, familyID string) (string, error)
+//! \brief Generates a refresh token in a family and stores its hash.
+//! \param tx Open transaction.
+//! \param userID ID of the user.
+//! \param familyID ID of the token family.
+//! \return Refresh token and error (if any).
+func issueRefreshToken(tx *sql.Tx, userID int, familyID string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	query := `INSERT INTO refresh_tokens (user_id, token, family_id, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(query, userID, hashToken(token), familyID, time.Now().UTC().Add(refreshTokenTTL)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+//! \fn startFamily(userID int, client Client) (string, string, error)
+//! \brief Starts a token family (session) for a new login and issues its first refresh token.
+//! \param userID ID of the user.
+//! \param client Device the user logged in from.
+//! \return Session ID, refresh token and error (if any).
+func (s *Service) startFamily(userID int, client Client) (string, string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+
+	familyID := uuid.New().String()
+	query := `INSERT INTO token_families (id, user_id, user_agent, ip, last_used_at) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.Exec(query, familyID, userID, clientAgent(client), client.IP, time.Now().UTC()); err != nil {
+		return "", "", err
+	}
+	token, err := issueRefreshToken(tx, userID, familyID)
+	if err != nil {
+		return "", "", err
+	}
+	return familyID, token, tx.Commit()
+}
+
+//! \fn Refresh(refreshToken string, client Client) (string, string, error)
+//! \brief Exchanges a refresh token for a new access token and a new refresh token.
+//!        Every refresh token is single-use; presenting one that was already rotated out
+//!        means it leaked, so its whole family is revoked.
+//! \param refreshToken Refresh token to validate.
+//! \param client Device the token is presented from; recorded as the session's last use.
+//! \return New access token, new refresh token and error (if any).
+func (s *Service) Refresh(refreshToken string, client Client) (string, string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.Logger.Error("Failed to begin transaction", zap.Error(err))
+		return "", "", err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var familyID string
+	var expiresAt time.Time
+	var usedAt, revokedAt *time.Time
+	query := `SELECT t.user_id, t.family_id, t.expires_at, t.used_at, f.revoked_at
+              FROM refresh_tokens t JOIN token_families f ON f.id = t.family_id
+              WHERE t.token = $1 FOR UPDATE OF t, f`
+	hash := hashToken(refreshToken)
+	err = tx.QueryRow(query, hash).Scan(&userID, &familyID, &expiresAt, &usedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		s.Logger.Warn("Unknown refresh token")
+		return "", "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		s.Logger.Error("Failed to fetch refresh token", zap.Error(err))
+		return "", "", err
+	}
+
+	now := time.Now().UTC()
+	if revokedAt != nil {
+		s.Logger.Warn("Refresh token of revoked family", zap.Int("user_id", userID), zap.String("family_id", familyID))
+		return "", "", ErrInvalidRefreshToken
+	}
+	if usedAt != nil {
+		if _, err := tx.Exec(`UPDATE token_families SET revoked_at = $2 WHERE id = $1`, familyID, now); err != nil {
+			s.Logger.Error("Failed to revoke token family", zap.Error(err))
+			return "", "", err
+		}
+		if err := tx.Commit(); err != nil {
+			s.Logger.Error("Failed to commit transaction", zap.Error(err))
+			return "", "", err
+		}
+		s.Logger.Warn("Security event: refresh token reuse detected, token family revoked",
+			zap.Int("user_id", userID), zap.String("family_id", familyID), zap.Time("rotated_at", *usedAt))
+		s.publish(events.TokenReuseDetected, userID)
+		return "", "", ErrRefreshTokenReused
+	}
+	if now.After(expiresAt) {
+		s.Logger.Warn("Expired refresh token", zap.Int("user_id", userID))
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET used_at = $2 WHERE token = $1`, hash, now); err != nil {
+		s.Logger.Error("Failed to rotate refresh token", zap.Error(err))
+		return "", "", err
+	}
+	query = `UPDATE token_families SET last_used_at = $2, user_agent = $3, ip = $4 WHERE id = $1`
+	if _, err := tx.Exec(query, familyID, now, clientAgent(client), client.IP); err != nil {
+		s.Logger.Error("Failed to update session", zap.Error(err))
+		return "", "", err
+	}
+	newRefreshToken, err := issueRefreshToken(tx, userID, familyID)
+	if err != nil {
+		s.Logger.Error("Failed to generate refresh token", zap.Error(err))
+		return "", "", err
+	}
+
+	var username string
+	if err := tx.QueryRow(`SELECT username FROM users WHERE id = $1`, userID).Scan(&username); err != nil {
+		s.Logger.Error("Failed to fetch username", zap.Error(err))
+		return "", "", err
+	}
+	accessToken, err := s.generateAccessToken(userID, username, familyID)
+	if err != nil {
+		s.Logger.Error("Failed to generate access token", zap.Error(err))
+		return "", "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit transaction", zap.Error(err))
+		return "", "", err
+	}
+
+	s.Logger.Info("Token refreshed", zap.Int("user_id", userID))
+	s.publish(events.TokenRefreshed, userID)
+	return accessToken, newRefreshToken, nil
+}
+
+//! \fn CleanupTokens() error
+//! \brief Deletes expired refresh tokens, the families left without any, and expired password
+//!        reset, email verification, MFA challenge and stream tokens. Rotated-out refresh tokens are
+//!        kept until they expire so that their reuse is still detected.
+//! \return Error (if any).
+func (s *Service) CleanupTokens() error {
+	now := time.Now().UTC()
+	if _, err := s.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at < $1`, now); err != nil {
+		s.Logger.Error("Failed to delete expired refresh tokens", zap.Error(err))
+		return err
+	}
+	query := `DELETE FROM token_families f
+              WHERE NOT EXISTS (SELECT 1 FROM refresh_tokens t WHERE t.family_id = f.id)`
+	if _, err := s.db.Exec(query); err != nil {
+		s.Logger.Error("Failed to delete empty token families", zap.Error(err))
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM password_resets WHERE expires_at < $1`, now); err != nil {
+		s.Logger.Error("Failed to delete expired reset tokens", zap.Error(err))
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM email_verifications WHERE expires_at < $1`, now); err != nil {
+		s.Logger.Error("Failed to delete expired verification tokens", zap.Error(err))
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM mfa_challenges WHERE expires_at < $1`, now); err != nil {
+		s.Logger.Error("Failed to delete expired MFA challenges", zap.Error(err))
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM stream_tickets WHERE expires_at < $1`, now); err != nil {
+		s.Logger.Error("Failed to delete expired stream tickets", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+//! \fn RunTokenCleanup(ctx context.Context)
+//! \brief Periodically cleans up refresh tokens until the context is cancelled.
+//! \param ctx Context controlling the job's lifetime.
+func (s *Service) RunTokenCleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		s.CleanupTokens()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
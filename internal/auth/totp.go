@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//! \brief TOTP parameters (RFC 6238); these are the defaults every authenticator app supports.
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	totpSkew   = 1
+	totpSecret = 20
+	totpIssuer = "Task Tracker"
+)
+
+//! \var secretEncoding
+//! \brief Base32 without padding, as authenticator apps expect secrets.
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+//! \fn totpCode(secret []byte, step int64) string
+//! \brief Computes the code of a time step (RFC 4226 HOTP with the step as counter).
+//! \param secret Shared secret.
+//! \param step Number of periods since the Unix epoch.
+//! \return Zero-padded code.
+func totpCode(secret []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000) // 10^totpDigits
+}
+
+//! \fn checkTOTP(secret []byte, code string, now time.Time, lastStep int64) (int64, bool)
+//! \brief Checks a code against the current time step and its neighbours, allowing for clock
+//!        drift. Steps up to lastStep were already used and are refused, so a code works once.
+//! \param secret Shared secret.
+//! \param code Code entered by the user.
+//! \param now Current time.
+//! \param lastStep Last step a code was accepted for.
+//! \return Matched step, and whether the code is valid.
+func checkTOTP(secret []byte, code string, now time.Time, lastStep int64) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	if _, err := strconv.Atoi(code); err != nil {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+//! \fn otpauthURI(account string, secret []byte) string
+//! \brief Builds the otpauth:// URI that authenticator apps import, usually from a QR code.
+//! \param account Account name shown in the app.
+//! \param secret Shared secret.
+//! \return Key URI.
+func otpauthURI(account string, secret []byte) string {
+	params := url.Values{}
+	params.Set("secret", secretEncoding.EncodeToString(secret))
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", strconv.Itoa(totpDigits))
+	params.Set("period", strconv.Itoa(totpPeriod))
+	label := url.PathEscape(totpIssuer + ":" + account)
+	// Some authenticator apps do not decode "+" as a space in the issuer.
+	return "otpauth://totp/" + label + "?" + strings.ReplaceAll(params.Encode(), "+", "%20")
+}
+
+//! \fn mfaKey(key string) []byte
+//! \brief Derives the AES-256 key encrypting TOTP secrets from the configured key. It is kept apart
+//!        from the JWT secret so that rotating that secret leaves enrolled secrets readable.
+//! \param key Configured encryption key.
+//! \return 32-byte key, or nil if no key is configured.
+func mfaKey(key string) []byte {
+	if key == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+//! \fn sealSecret(secret []byte, userID int) ([]byte, error)
+//! \brief Encrypts a TOTP secret with AES-GCM. The user ID is authenticated as well, so a
+//!        ciphertext copied to another account does not decrypt.
+//! \param secret Plain secret.
+//! \param userID ID of the owning user.
+//! \return Nonce followed by the ciphertext, and error (if any).
+func (s *Service) sealSecret(secret []byte, userID int) ([]byte, error) {
+	aead, err := s.mfaCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, secret, []byte(strconv.Itoa(userID))), nil
+}
+
+//! \fn openSecret(sealed []byte, userID int) ([]byte, error)
+//! \brief Decrypts a TOTP secret sealed by sealSecret.
+//! \param sealed Nonce followed by the ciphertext.
+//! \param userID ID of the owning user.
+//! \return Plain secret and error (if any).
+func (s *Service) openSecret(sealed []byte, userID int) ([]byte, error) {
+	aead, err := s.mfaCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed secret too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(strconv.Itoa(userID)))
+}
+
+//! \fn mfaCipher() (cipher.AEAD, error)
+//! \brief Creates the AES-GCM cipher for TOTP secrets.
+//! \return Cipher and ErrMFAUnavailable or another error (if any).
+func (s *Service) mfaCipher() (cipher.AEAD, error) {
+	if s.mfaKey == nil {
+		return nil, ErrMFAUnavailable
+	}
+	block, err := aes.NewCipher(s.mfaKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors.
+var rfcSecret = []byte("12345678901234567890")
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B lists 8-digit codes; 6-digit codes are their last six digits.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(rfcSecret, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode(%d) = %q, want %q", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / totpPeriod
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", totpCode(rfcSecret, current), 0, current, true},
+		{"previous step", totpCode(rfcSecret, current-1), 0, current - 1, true},
+		{"next step", totpCode(rfcSecret, current+1), 0, current + 1, true},
+		{"outside the window", totpCode(rfcSecret, current-2), 0, 0, false},
+		{"replayed", totpCode(rfcSecret, current), current, 0, false},
+		{"older than the last step", totpCode(rfcSecret, current-1), current - 1, 0, false},
+		{"after the last step", totpCode(rfcSecret, current), current - 1, current, true},
+		{"wrong code", "000000", 0, 0, false},
+		{"too short", "05047", 0, 0, false},
+		{"not numeric", "05o471", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := checkTOTP(rfcSecret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("checkTOTP(%q) = (%d, %v), want (%d, %v)", tt.code, step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSealSecretWithoutKey(t *testing.T) {
+	s := &Service{mfaKey: mfaKey("")}
+	if _, err := s.sealSecret(rfcSecret, 7); !errors.Is(err, ErrMFAUnavailable) {
+		t.Errorf("sealSecret() error = %v, want ErrMFAUnavailable", err)
+	}
+	if _, err := s.openSecret([]byte("sealed secret"), 7); !errors.Is(err, ErrMFAUnavailable) {
+		t.Errorf("openSecret() error = %v, want ErrMFAUnavailable", err)
+	}
+}
+
+func TestSealSecret(t *testing.T) {
+	s := &Service{mfaKey: mfaKey("test key")}
+	sealed, err := s.sealSecret(rfcSecret, 7)
+	if err != nil {
+		t.Fatalf("sealSecret() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		key    string
+		userID int
+		sealed []byte
+		wantOK bool
+	}{
+		{"same user", "test key", 7, sealed, true},
+		{"other user", "test key", 8, sealed, false},
+		{"other key", "other key", 7, sealed, false},
+		{"truncated", "test key", 7, sealed[:4], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{mfaKey: mfaKey(tt.key)}
+			secret, err := s.openSecret(tt.sealed, tt.userID)
+			if tt.wantOK && (err != nil || !bytes.Equal(secret, rfcSecret)) {
+				t.Errorf("openSecret() = (%q, %v), want the sealed secret", secret, err)
+			}
+			if !tt.wantOK && err == nil {
+				t.Errorf("openSecret() succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"task-tracker/internal/events"
+	"task-tracker/internal/mail"
+	"go.uber.org/zap"
+)
+
+//! \const verifyTokenTTL
+//! \brief How long an email verification link stays valid.
+const verifyTokenTTL = 24 * time.Hour
+
+//! \var ErrInvalidVerifyToken
+//! \brief Returned when an email verification token is unknown or expired.
+var ErrInvalidVerifyToken = errors.New("invalid or expired verification token")
+
+//! \var ErrEmailNotVerified
+//! \brief Returned when an action requires a verified email address.
+var ErrEmailNotVerified = errors.New("email not verified")
+
+//! \fn sendVerification(userID int, username, email string) error
+//! \brief Issues a verification token, replacing any pending one, and emails the link.
+//! \param userID ID of the user.
+//! \param username User's username, used in the greeting.
+//! \param email Address to verify.
+//! \return Error (if any).
+func (s *Service) sendVerification(userID int, username, email string) error {
+	token, err := newToken()
+	if err != nil {
+		s.Logger.Error("Failed to generate verification token", zap.Error(err))
+		return err
+	}
+
+	query := `INSERT INTO email_verifications (user_id, token_hash, expires_at) VALUES ($1, $2, $3)
+              ON CONFLICT (user_id) DO UPDATE
+              SET token_hash = EXCLUDED.token_hash, expires_at = EXCLUDED.expires_at, created_at = CURRENT_TIMESTAMP`
+	if _, err := s.db.Exec(query, userID, hashToken(token), time.Now().UTC().Add(verifyTokenTTL)); err != nil {
+		s.Logger.Error("Failed to store verification token", zap.Error(err))
+		return err
+	}
+
+	link := s.appURL + "/?verify_token=" + url.QueryEscape(token)
+	s.sendMail(mail.Message{
+		To:      email,
+		Subject: "Confirm your Task Tracker email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm that this is your email address by opening this link "+
+			"within %d hours:\n\n%s\n\nIf you did not create an account, ignore this email.\n",
+			username, int(verifyTokenTTL.Hours()), link),
+	})
+	s.Logger.Info("Verification email sent", zap.Int("user_id", userID))
+	return nil
+}
+
+//! \fn VerifyEmail(token string) error
+//! \brief Confirms a user's email address with the token from the emailed link.
+//! \param token Verification token.
+//! \return Error (if any); ErrInvalidVerifyToken if the token cannot be used.
+func (s *Service) VerifyEmail(token string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.Logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	now := time.Now().UTC()
+	query := `DELETE FROM email_verifications WHERE token_hash = $1 AND expires_at > $2 RETURNING user_id`
+	err = tx.QueryRow(query, hashToken(token), now).Scan(&userID)
+	if err == sql.ErrNoRows {
+		s.Logger.Warn("Invalid email verification token")
+		return ErrInvalidVerifyToken
+	}
+	if err != nil {
+		s.Logger.Error("Failed to fetch verification token", zap.Error(err))
+		return err
+	}
+
+	query = `UPDATE users SET email_verified_at = $2 WHERE id = $1 AND email_verified_at IS NULL`
+	if _, err := tx.Exec(query, userID, now); err != nil {
+		s.Logger.Error("Failed to verify email", zap.Error(err))
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.Logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	s.Logger.Info("Email verified", zap.Int("user_id", userID))
+	s.publish(events.EmailVerified, userID)
+	return nil
+}
+
+//! \fn ResendVerification(email string) error
+//! \brief Sends a new verification link if an account uses the address and has not verified it.
+//!        Other addresses are not an error, so callers cannot tell whether an account exists.
+//! \param email Email address entered by the user.
+//! \return Error (if any).
+func (s *Service) ResendVerification(email string) error {
+	var userID int
+	var username, address string
+	query := `SELECT id, username, email FROM users WHERE LOWER(email) = LOWER($1) AND email_verified_at IS NULL`
+	err := s.db.QueryRow(query, strings.TrimSpace(email)).Scan(&userID, &username, &address)
+	if err == sql.ErrNoRows {
+		s.Logger.Info("Verification resend requested for unknown or verified email")
+		return nil
+	}
+	if err != nil {
+		s.Logger.Error("Failed to fetch user", zap.Error(err))
+		return err
+	}
+	return s.sendVerification(userID, username, address)
+}
+
+//! \fn EmailVerified(userID int) (bool, error)
+//! \brief Checks whether a user has verified their email address.
+//! \param userID ID of the user.
+//! \return Whether the address is verified and error (if any).
+func (s *Service) EmailVerified(userID int) (bool, error) {
+	var verified bool
+	query := `SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`
+	if err := s.db.QueryRow(query, userID).Scan(&verified); err != nil {
+		s.Logger.Error("Failed to check email verification", zap.Error(err))
+		return false, err
+	}
+	return verified, nil
+}
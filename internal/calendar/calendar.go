@@ -0,0 +1,109 @@
+package calendar
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+//! \fn GetFeedHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to show whether the user has a calendar feed.
+//! \param s Calendar service instance.
+//! \return Gin handler function.
+func GetFeedHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		feed, err := s.GetFeed(userID.(int))
+		if err != nil {
+			respondError(c, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, feed)
+	}
+}
+
+//! \fn RegenerateFeedHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to issue a new feed URL, revoking the previous one.
+//! \param s Calendar service instance.
+//! \return Gin handler function.
+func RegenerateFeedHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		feed, err := s.RegenerateFeed(userID.(int))
+		if err != nil {
+			respondError(c, err, "Failed to create calendar feed")
+			return
+		}
+		c.JSON(http.StatusCreated, feed)
+	}
+}
+
+//! \fn RevokeFeedHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to revoke the user's feed URL.
+//! \param s Calendar service instance.
+//! \return Gin handler function.
+func RevokeFeedHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		if err := s.RevokeFeed(userID.(int)); err != nil {
+			respondError(c, err, "Failed to revoke calendar feed")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked"})
+	}
+}
+
+//! \fn FeedHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler serving a feed by its secret URL, for calendar apps that cannot
+//!        send credentials. The component query parameter selects vevent (default) or vtodo.
+//! \param s Calendar service instance.
+//! \return Gin handler function.
+func FeedHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		component := ComponentEvent
+		switch strings.ToLower(c.Query("component")) {
+		case "", "vevent":
+		case "vtodo":
+			component = ComponentTodo
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "component must be vevent or vtodo"})
+			return
+		}
+
+		tasks, err := s.FeedTasks(c.Param("token"))
+		if err != nil {
+			respondError(c, err, "Internal server error")
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := Render(&buf, tasks, component, time.Now()); err != nil {
+			s.logger.Error("Failed to render calendar feed", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.Header("Content-Disposition", `inline; filename="tasks.ics"`)
+		c.Header("Cache-Control", "private, max-age=300")
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+	}
+}
+
+//! \fn respondError(c *gin.Context, err error, message string)
+//! \brief Writes the response for a failed calendar operation.
+//! \param c Gin context.
+//! \param err Error returned by the service.
+//! \param message Message used for unexpected errors.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,131 @@
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"task-tracker/internal/models"
+)
+
+//! \brief Calendar components a feed can render tasks as.
+const (
+	ComponentEvent = "VEVENT"
+	ComponentTodo  = "VTODO"
+)
+
+//! \const maxLineOctets
+//! \brief Longest content line allowed by RFC 5545 before folding, excluding CRLF.
+const maxLineOctets = 75
+
+//! \const utcFormat
+//! \brief RFC 5545 DATE-TIME in UTC. Stored timestamps are UTC, so no VTIMEZONE is needed
+//!        and calendar apps show the entries in the viewer's own zone.
+const utcFormat = "20060102T150405Z"
+
+//! \var todoStatuses
+//! \brief Maps task statuses to VTODO statuses.
+var todoStatuses = map[string]string{
+	"pending":     "NEEDS-ACTION",
+	"in_progress": "IN-PROCESS",
+	"done":        "COMPLETED",
+}
+
+//! \var textEscaper
+//! \brief Escapes TEXT property values (RFC 5545 section 3.3.11).
+var textEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+//! \struct icsWriter
+//! \brief Writes folded, CRLF-terminated content lines, remembering the first error.
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+//! \fn line(name, value string)
+//! \brief Writes a property, folding it into 75-octet lines without splitting UTF-8 characters.
+//! \param name Property name including parameters.
+//! \param value Property value, already escaped.
+func (iw *icsWriter) line(name, value string) {
+	if iw.err != nil {
+		return
+	}
+	content := name + ":" + value
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		iw.write(content[:cut] + "\r\n ")
+		content = content[cut:]
+		// Continuation lines start with a space, which counts towards their length.
+		limit = maxLineOctets - 1
+	}
+	iw.write(content + "\r\n")
+}
+
+//! \fn write(s string)
+//! \brief Writes raw output.
+//! \param s Output.
+func (iw *icsWriter) write(s string) {
+	if iw.err == nil {
+		_, iw.err = iw.w.WriteString(s)
+	}
+}
+
+//! \fn Render(w io.Writer, tasks []models.Task, component string, now time.Time) error
+//! \brief Renders tasks with due dates as an iCalendar document.
+//! \param w Destination.
+//! \param tasks Tasks to render; tasks without a due date are skipped.
+//! \param component ComponentEvent or ComponentTodo.
+//! \param now Time used as the DTSTAMP of every entry.
+//! \return Error (if any).
+func Render(w io.Writer, tasks []models.Task, component string, now time.Time) error {
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+	stamp := now.UTC().Format(utcFormat)
+
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//Task Tracker//Task Feed//EN")
+	iw.line("CALSCALE", "GREGORIAN")
+	iw.line("METHOD", "PUBLISH")
+	iw.line("X-WR-CALNAME", "Tasks")
+	iw.line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	iw.line("X-PUBLISHED-TTL", "PT1H")
+	for _, task := range tasks {
+		if task.DueDate == nil {
+			continue
+		}
+		due := task.DueDate.UTC().Format(utcFormat)
+		iw.line("BEGIN", component)
+		iw.line("UID", "task-"+strconv.Itoa(task.ID)+"@task-tracker")
+		iw.line("DTSTAMP", stamp)
+		iw.line("CREATED", task.CreatedAt.UTC().Format(utcFormat))
+		iw.line("SEQUENCE", strconv.Itoa(task.Version-1))
+		iw.line("SUMMARY", textEscaper.Replace(task.Title))
+		if task.Description != "" {
+			iw.line("DESCRIPTION", textEscaper.Replace(task.Description))
+		}
+		if component == ComponentTodo {
+			iw.line("DUE", due)
+			if status, ok := todoStatuses[task.Status]; ok {
+				iw.line("STATUS", status)
+			}
+		} else {
+			// A DATE-TIME start without an end is a zero-length event; it should not block busy time.
+			iw.line("DTSTART", due)
+			iw.line("TRANSP", "TRANSPARENT")
+		}
+		iw.line("END", component)
+	}
+	iw.line("END", "VCALENDAR")
+
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
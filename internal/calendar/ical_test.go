@@ -0,0 +1,122 @@
+package calendar
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"task-tracker/internal/models"
+)
+
+func TestTextEscaper(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{`back\slash`, `back\\slash`},
+		{"a;b,c", `a\;b\,c`},
+		{"line\nbreak", `line\nbreak`},
+		{"windows\r\nbreak", `windows\nbreak`},
+		{"old mac\rbreak", `old mac\nbreak`},
+		{`\n is not a newline`, `\\n is not a newline`},
+		{"colon: stays", "colon: stays"},
+	}
+	for _, tt := range tests {
+		if got := textEscaper.Replace(tt.in); got != tt.want {
+			t.Errorf("textEscaper.Replace(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		lines int
+	}{
+		{"short", "Call Bob", 1},
+		{"exactly 75 octets", strings.Repeat("a", maxLineOctets-len("SUMMARY:")), 1},
+		{"76 octets", strings.Repeat("a", maxLineOctets-len("SUMMARY:")+1), 2},
+		{"long ASCII", strings.Repeat("abcdefghij", 20), 3},
+		{"multi-byte characters", strings.Repeat("äöü€😀", 20), 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			iw := &icsWriter{w: bufio.NewWriter(&out)}
+			iw.line("SUMMARY", tt.value)
+			if err := iw.w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			text := out.String()
+			if !strings.HasSuffix(text, "\r\n") {
+				t.Fatalf("output %q does not end with CRLF", text)
+			}
+			lines := strings.Split(strings.TrimSuffix(text, "\r\n"), "\r\n")
+			if len(lines) != tt.lines {
+				t.Errorf("got %d lines, want %d", len(lines), tt.lines)
+			}
+			for i, line := range lines {
+				if len(line) > maxLineOctets {
+					t.Errorf("line %d has %d octets", i, len(line))
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a character: %q", i, line)
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space", i)
+				}
+			}
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(text, "\r\n"), "\r\n ", ""); unfolded != "SUMMARY:"+tt.value {
+				t.Errorf("unfolded = %q, want %q", unfolded, "SUMMARY:"+tt.value)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	due := time.Date(2026, 3, 10, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tasks := []models.Task{
+		{ID: 1, Title: "Pay rent; utilities", Status: "in_progress", DueDate: &due, CreatedAt: created, Version: 2},
+		{ID: 2, Title: "Someday", Status: "pending", CreatedAt: created, Version: 1},
+	}
+	tests := []struct {
+		name      string
+		component string
+		want      []string
+	}{
+		{"events", ComponentEvent, []string{
+			"BEGIN:VEVENT", "UID:task-1@task-tracker", "DTSTAMP:20260301T120000Z", "SEQUENCE:1",
+			`SUMMARY:Pay rent\; utilities`, "DTSTART:20260310T083000Z", "TRANSP:TRANSPARENT",
+		}},
+		{"todos", ComponentTodo, []string{
+			"BEGIN:VTODO", "DUE:20260310T083000Z", "STATUS:IN-PROCESS",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := Render(&out, tasks, tt.component, now); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			text := out.String()
+			if !strings.HasPrefix(text, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(text, "END:VCALENDAR\r\n") {
+				t.Errorf("output is not a calendar: %q", text)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(text, "\r\n"+line+"\r\n") {
+					t.Errorf("output lacks line %q", line)
+				}
+			}
+			if strings.Contains(text, "task-2@") || strings.Count(text, "BEGIN:"+tt.component) != 1 {
+				t.Errorf("undated task was rendered: %q", text)
+			}
+		})
+	}
+}
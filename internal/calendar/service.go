@@ -0,0 +1,160 @@
+package calendar
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \const feedSuffix
+//! \brief File extension expected after the token in feed URLs.
+const feedSuffix = ".ics"
+
+//! \struct Service
+//! \brief Handles calendar feed tokens and the tasks shown in feeds.
+type Service struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+//! \fn NewService(db *sql.DB, logger *zap.Logger) *Service
+//! \brief Initializes a new calendar service.
+//! \param db Database connection.
+//! \param logger Logger instance.
+//! \return Pointer to initialized Service.
+func NewService(db *sql.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		logger: logger,
+	}
+}
+
+//! \fn hashToken(token string) string
+//! \brief Hashes a feed token for storage and lookup.
+//! \param token Feed token.
+//! \return Hex-encoded SHA-256 digest.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+//! \fn feedPath(token string) string
+//! \brief Builds the path of a feed.
+//! \param token Feed token.
+//! \return Path relative to the API root.
+func feedPath(token string) string {
+	return "/calendar/" + token + feedSuffix
+}
+
+//! \fn GetFeed(userID int) (*models.CalendarFeed, error)
+//! \brief Retrieves the state of a user's feed; the token itself is not recoverable.
+//! \param userID ID of the user.
+//! \return Feed and error (if any); sql.ErrNoRows if the user has no feed.
+func (s *Service) GetFeed(userID int) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	query := `SELECT created_at, last_used_at FROM calendar_feeds WHERE user_id = $1`
+	if err := s.db.QueryRow(query, userID).Scan(&feed.CreatedAt, &feed.LastUsedAt); err != nil {
+		if err != sql.ErrNoRows {
+			s.logger.Error("Failed to fetch calendar feed", zap.Error(err))
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+//! \fn RegenerateFeed(userID int) (*models.CalendarFeed, error)
+//! \brief Issues a new feed token, revoking any previous one.
+//! \param userID ID of the user.
+//! \return Feed including its token and URL path, and error (if any).
+func (s *Service) RegenerateFeed(userID int) (*models.CalendarFeed, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		s.logger.Error("Failed to generate feed token", zap.Error(err))
+		return nil, err
+	}
+	feed := models.CalendarFeed{Token: hex.EncodeToString(buf)}
+	feed.URL = feedPath(feed.Token)
+
+	query := `INSERT INTO calendar_feeds (user_id, token_hash) VALUES ($1, $2)
+              ON CONFLICT (user_id) DO UPDATE
+              SET token_hash = EXCLUDED.token_hash, created_at = CURRENT_TIMESTAMP, last_used_at = NULL
+              RETURNING created_at`
+	if err := s.db.QueryRow(query, userID, hashToken(feed.Token)).Scan(&feed.CreatedAt); err != nil {
+		s.logger.Error("Failed to store feed token", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Calendar feed token issued", zap.Int("user_id", userID))
+	return &feed, nil
+}
+
+//! \fn RevokeFeed(userID int) error
+//! \brief Deletes a user's feed token so its URL stops working.
+//! \param userID ID of the user.
+//! \return Error (if any); sql.ErrNoRows if the user has no feed.
+func (s *Service) RevokeFeed(userID int) error {
+	result, err := s.db.Exec(`DELETE FROM calendar_feeds WHERE user_id = $1`, userID)
+	if err != nil {
+		s.logger.Error("Failed to revoke calendar feed", zap.Error(err))
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	s.logger.Info("Calendar feed revoked", zap.Int("user_id", userID))
+	return nil
+}
+
+//! \fn FeedTasks(file string) ([]models.Task, error)
+//! \brief Resolves a feed file name ("<token>.ics") and loads its owner's tasks that have a due date,
+//!        leaving out archived projects like the task list does.
+//! \param file Last path segment of the feed URL.
+//! \return Tasks ordered by due date and error (if any); sql.ErrNoRows for unknown tokens.
+func (s *Service) FeedTasks(file string) ([]models.Task, error) {
+	token := strings.TrimSuffix(file, feedSuffix)
+	if token == file || token == "" {
+		return nil, sql.ErrNoRows
+	}
+
+	var userID int
+	query := `UPDATE calendar_feeds SET last_used_at = CURRENT_TIMESTAMP WHERE token_hash = $1 RETURNING user_id`
+	if err := s.db.QueryRow(query, hashToken(token)).Scan(&userID); err != nil {
+		if err != sql.ErrNoRows {
+			s.logger.Error("Failed to resolve calendar feed", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	query = `SELECT id, title, COALESCE(description, ''), status, due_date, created_at, version FROM tasks
+             WHERE user_id = $1 AND deleted_at IS NULL AND due_date IS NOT NULL
+               AND NOT EXISTS (SELECT 1 FROM projects p WHERE p.id = tasks.project_id AND p.archived_at IS NOT NULL)
+             ORDER BY due_date, id`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch feed tasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		var task models.Task
+		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Status, &task.DueDate,
+			&task.CreatedAt, &task.Version); err != nil {
+			s.logger.Error("Failed to scan task", zap.Error(err))
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate tasks", zap.Error(err))
+		return nil, err
+	}
+	return tasks, nil
+}
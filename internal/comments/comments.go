@@ -0,0 +1,137 @@
+package comments
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"task-tracker/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+//! \fn GetCommentsHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list a page of a task's comments.
+//! \param s Comment service instance.
+//! \return Gin handler function.
+func GetCommentsHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := 0
+		if raw := c.Query("limit"); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil || v < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+				return
+			}
+			limit = v
+		}
+
+		userID, _ := c.Get("user_id")
+		comments, nextCursor, err := s.GetComments(c.Param("id"), userID.(int), c.Query("cursor"), limit)
+		if err != nil {
+			respondError(c, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"comments": comments, "next_cursor": nextCursor})
+	}
+}
+
+//! \fn AddCommentHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to comment on a task.
+//! \param s Comment service instance.
+//! \return Gin handler function.
+func AddCommentHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var comment models.Comment
+		if !bindComment(c, s, &comment) {
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		if err := s.AddComment(c.Param("id"), userID.(int), &comment); err != nil {
+			respondError(c, err, "Failed to add comment")
+			return
+		}
+		c.JSON(http.StatusCreated, comment)
+	}
+}
+
+//! \fn EditCommentHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to edit a comment.
+//! \param s Comment service instance.
+//! \return Gin handler function.
+func EditCommentHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input models.Comment
+		if !bindComment(c, s, &input) {
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		comment, err := s.EditComment(c.Param("id"), c.Param("comment_id"), userID.(int), input.Body)
+		if err != nil {
+			respondError(c, err, "Failed to edit comment")
+			return
+		}
+		c.JSON(http.StatusOK, comment)
+	}
+}
+
+//! \fn DeleteCommentHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to delete a comment.
+//! \param s Comment service instance.
+//! \return Gin handler function.
+func DeleteCommentHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		if err := s.DeleteComment(c.Param("id"), c.Param("comment_id"), userID.(int)); err != nil {
+			respondError(c, err, "Failed to delete comment")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+	}
+}
+
+//! \fn bindComment(c *gin.Context, s *Service, comment *models.Comment) bool
+//! \brief Binds and validates a comment request body.
+//! \param c Gin context.
+//! \param s Comment service instance.
+//! \param comment Destination comment.
+//! \return False if a response has already been written.
+func bindComment(c *gin.Context, s *Service, comment *models.Comment) bool {
+	if err := c.ShouldBindJSON(comment); err != nil {
+		s.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return false
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(comment); err != nil {
+		s.logger.Warn("Validation failed", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+//! \fn respondError(c *gin.Context, err error, message string)
+//! \brief Writes the response for a failed comment operation.
+//! \param c Gin context.
+//! \param err Error returned by the service.
+//! \param message Message used for unexpected errors.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+	case errors.Is(err, ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,213 @@
+package comments
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+//! \var ErrTaskNotFound
+//! \brief Returned when the task does not exist, is trashed or belongs to another user.
+var ErrTaskNotFound = errors.New("task not found")
+
+//! \var ErrForbidden
+//! \brief Returned when a user edits or deletes a comment they may not change.
+var ErrForbidden = errors.New("not allowed to change this comment")
+
+//! \var ErrInvalidCursor
+//! \brief Returned when a pagination cursor is malformed.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+//! \struct Service
+//! \brief Handles task comment business logic.
+type Service struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+//! \fn NewService(db *sql.DB, logger *zap.Logger) *Service
+//! \brief Initializes a new comment service.
+//! \param db Database connection.
+//! \param logger Logger instance.
+//! \return Pointer to initialized Service.
+func NewService(db *sql.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		logger: logger,
+	}
+}
+
+//! \fn checkTask(taskID string, userID int) error
+//! \brief Verifies that the user may access the task, following the task service's ownership rules.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \return ErrTaskNotFound or another error (if any).
+func (s *Service) checkTask(taskID string, userID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+	if err := s.db.QueryRow(query, taskID, userID).Scan(&exists); err != nil {
+		s.logger.Error("Failed to check task ownership", zap.Error(err))
+		return err
+	}
+	if !exists {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+//! \fn GetComments(taskID string, userID int, cursor string, limit int) ([]models.Comment, string, error)
+//! \brief Retrieves a page of a task's comments, oldest first.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \param cursor next_cursor of the previous page; empty for the first page.
+//! \param limit Page size.
+//! \return List of comments, cursor of the next page (empty on the last page) and error (if any).
+func (s *Service) GetComments(taskID string, userID int, cursor string, limit int) ([]models.Comment, string, error) {
+	if err := s.checkTask(taskID, userID); err != nil {
+		return nil, "", err
+	}
+
+	after := 0
+	if cursor != "" {
+		v, err := strconv.Atoi(cursor)
+		if err != nil || v < 0 {
+			return nil, "", ErrInvalidCursor
+		}
+		after = v
+	}
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	query := `SELECT id, task_id, author_id, body, created_at, edited_at FROM task_comments
+              WHERE task_id = $1 AND id > $2 ORDER BY id LIMIT $3`
+	rows, err := s.db.Query(query, taskID, after, limit+1)
+	if err != nil {
+		s.logger.Error("Failed to fetch comments", zap.Error(err))
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(&comment.ID, &comment.TaskID, &comment.AuthorID, &comment.Body,
+			&comment.CreatedAt, &comment.EditedAt); err != nil {
+			s.logger.Error("Failed to scan comment", zap.Error(err))
+			return nil, "", err
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate comments", zap.Error(err))
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(comments) > limit {
+		comments = comments[:limit]
+		nextCursor = strconv.Itoa(comments[len(comments)-1].ID)
+	}
+	return comments, nextCursor, nil
+}
+
+//! \fn AddComment(taskID string, userID int, comment *models.Comment) error
+//! \brief Adds a comment to a task.
+//! \param taskID ID of the task.
+//! \param userID ID of the author.
+//! \param comment Comment data; receives its stored values.
+//! \return Error (if any).
+func (s *Service) AddComment(taskID string, userID int, comment *models.Comment) error {
+	if err := s.checkTask(taskID, userID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO task_comments (task_id, author_id, body) VALUES ($1, $2, $3)
+              RETURNING id, task_id, author_id, created_at`
+	err := s.db.QueryRow(query, taskID, userID, comment.Body).
+		Scan(&comment.ID, &comment.TaskID, &comment.AuthorID, &comment.CreatedAt)
+	if err != nil {
+		s.logger.Error("Failed to add comment", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Comment added", zap.Int("comment_id", comment.ID), zap.String("task_id", taskID))
+	return nil
+}
+
+//! \fn EditComment(taskID, commentID string, userID int, body string) (*models.Comment, error)
+//! \brief Changes the body of a comment; only its author may edit it.
+//! \param taskID ID of the task.
+//! \param commentID ID of the comment.
+//! \param userID ID of the user.
+//! \param body New comment body.
+//! \return Updated comment and error (if any).
+func (s *Service) EditComment(taskID, commentID string, userID int, body string) (*models.Comment, error) {
+	if err := s.checkTask(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	var authorID int
+	query := `SELECT author_id FROM task_comments WHERE id = $1 AND task_id = $2`
+	if err := s.db.QueryRow(query, commentID, taskID).Scan(&authorID); err != nil {
+		s.logger.Warn("Comment not found", zap.String("comment_id", commentID), zap.Error(err))
+		return nil, err
+	}
+	if authorID != userID {
+		return nil, ErrForbidden
+	}
+
+	var comment models.Comment
+	query = `UPDATE task_comments SET body = $1, edited_at = CURRENT_TIMESTAMP
+             WHERE id = $2 AND author_id = $3
+             RETURNING id, task_id, author_id, body, created_at, edited_at`
+	err := s.db.QueryRow(query, body, commentID, userID).Scan(&comment.ID, &comment.TaskID,
+		&comment.AuthorID, &comment.Body, &comment.CreatedAt, &comment.EditedAt)
+	if err != nil {
+		s.logger.Error("Failed to edit comment", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Comment edited", zap.String("comment_id", commentID), zap.Int("user_id", userID))
+	return &comment, nil
+}
+
+//! \fn DeleteComment(taskID, commentID string, userID int) error
+//! \brief Deletes a comment; its author and the task owner may delete it.
+//! \param taskID ID of the task.
+//! \param commentID ID of the comment.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) DeleteComment(taskID, commentID string, userID int) error {
+	// checkTask succeeding means the user owns the task.
+	if err := s.checkTask(taskID, userID); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM task_comments WHERE id = $1 AND task_id = $2`
+	result, err := s.db.Exec(query, commentID, taskID)
+	if err != nil {
+		s.logger.Error("Failed to delete comment", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		s.logger.Warn("Comment not found", zap.String("comment_id", commentID))
+		return sql.ErrNoRows
+	}
+
+	s.logger.Info("Comment deleted", zap.String("comment_id", commentID), zap.Int("user_id", userID))
+	return nil
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"strings"
+	"time"
 	//"os"
 
 	"github.com/spf13/viper"
@@ -10,9 +12,31 @@ import (
 //! \struct Config
 //! \brief Holds application configuration settings.
 type Config struct {
-	Port        string
-	DatabaseURL string
-	JWTSecret   string
+	Port               string
+	DatabaseURL        string
+	JWTSecret          string
+	RequireIfMatch     bool
+	TrashRetention     time.Duration
+	StorageBackend     string
+	StorageDir         string
+	S3Endpoint         string
+	S3Bucket           string
+	S3Region           string
+	S3AccessKey        string
+	S3SecretKey        string
+	AttachmentMaxBytes int64
+	AttachmentTypes    []string
+	ReminderInterval   time.Duration
+	AppURL             string
+	MailBackend        string
+	MailDir            string
+	MailFrom           string
+	SMTPHost           string
+	SMTPPort           string
+	SMTPUsername       string
+	SMTPPassword       string
+	RequireVerified    string
+	MFASecretKey       string
 }
 
 //! \fn Load() (*Config, error)
@@ -33,14 +57,77 @@ func Load() (*Config, error) {
 	}
 
 	cfg := &Config{
-		Port:        v.GetString("port"),
-		DatabaseURL: v.GetString("database_url"),
-		JWTSecret:   v.GetString("jwt_secret"),
+		Port:               v.GetString("port"),
+		DatabaseURL:        v.GetString("database_url"),
+		JWTSecret:          v.GetString("jwt_secret"),
+		RequireIfMatch:     v.GetBool("require_if_match"),
+		TrashRetention:     v.GetDuration("trash_retention"),
+		StorageBackend:     v.GetString("storage_backend"),
+		StorageDir:         v.GetString("storage_dir"),
+		S3Endpoint:         v.GetString("s3_endpoint"),
+		S3Bucket:           v.GetString("s3_bucket"),
+		S3Region:           v.GetString("s3_region"),
+		S3AccessKey:        v.GetString("s3_access_key"),
+		S3SecretKey:        v.GetString("s3_secret_key"),
+		AttachmentMaxBytes: v.GetInt64("attachment_max_bytes"),
+		ReminderInterval:   v.GetDuration("reminder_interval"),
+		AppURL:             v.GetString("app_url"),
+		MailBackend:        v.GetString("mail_backend"),
+		MailDir:            v.GetString("mail_dir"),
+		MailFrom:           v.GetString("mail_from"),
+		SMTPHost:           v.GetString("smtp_host"),
+		SMTPPort:           v.GetString("smtp_port"),
+		SMTPUsername:       v.GetString("smtp_username"),
+		SMTPPassword:       v.GetString("smtp_password"),
+		RequireVerified:    v.GetString("require_verified_email"),
+		MFASecretKey:       v.GetString("mfa_secret_key"),
+	}
+	for _, t := range strings.Split(v.GetString("attachment_allowed_types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			cfg.AttachmentTypes = append(cfg.AttachmentTypes, t)
+		}
 	}
 
 	if cfg.Port == "" {
 		cfg.Port = "8080"
 	}
+	if cfg.TrashRetention <= 0 {
+		cfg.TrashRetention = 30 * 24 * time.Hour
+	}
+	if cfg.StorageBackend == "" {
+		cfg.StorageBackend = "local"
+	}
+	if cfg.StorageBackend != "local" && cfg.StorageBackend != "s3" {
+		return nil, fmt.Errorf("storage_backend must be local or s3")
+	}
+	if cfg.StorageDir == "" {
+		cfg.StorageDir = "data/attachments"
+	}
+	if cfg.AttachmentMaxBytes <= 0 {
+		cfg.AttachmentMaxBytes = 10 << 20
+	}
+	if cfg.ReminderInterval <= 0 {
+		cfg.ReminderInterval = 30 * time.Second
+	}
+	if cfg.AppURL == "" {
+		cfg.AppURL = "http://localhost:3000"
+	}
+	if cfg.MailBackend == "" {
+		cfg.MailBackend = "file"
+	}
+	if cfg.MailBackend != "file" && cfg.MailBackend != "smtp" {
+		return nil, fmt.Errorf("mail_backend must be file or smtp")
+	}
+	switch cfg.RequireVerified {
+	case "", "off":
+		cfg.RequireVerified = "off"
+	case "login", "tasks":
+	default:
+		return nil, fmt.Errorf("require_verified_email must be off, login or tasks")
+	}
+	if cfg.MailFrom == "" {
+		cfg.MailFrom = "Task Tracker <no-reply@localhost>"
+	}
 	if cfg.DatabaseURL == "" {
 		return nil, fmt.Errorf("database_url is required")
 	}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+//! \brief Event types published on the bus.
+const (
+	TaskCreated        = "task.created"
+	TaskUpdated        = "task.updated"
+	TaskDeleted        = "task.deleted"
+	UserRegistered     = "user.registered"
+	UserLoggedIn       = "user.logged_in"
+	TokenRefreshed     = "user.token_refreshed"
+	TokenReuseDetected = "user.token_reuse_detected"
+	SessionRevoked     = "user.session_revoked"
+	PasswordReset      = "user.password_reset"
+	EmailVerified      = "user.email_verified"
+	MFAEnabled         = "user.mfa_enabled"
+	MFADisabled        = "user.mfa_disabled"
+
+	//! \brief Delivered locally when the bus had to reconnect; events may have been lost
+	//!        and subscribers should resynchronize from the database.
+	Reconnected = "bus.reconnected"
+)
+
+//! \const subscriptionBuffer
+//! \brief Events a subscriber may fall behind by before its subscription is closed.
+const subscriptionBuffer = 1024
+
+//! \var ErrTooLarge
+//! \brief Returned when an event does not fit into a Postgres notification.
+var ErrTooLarge = errors.New("event payload too large")
+
+//! \struct Event
+//! \brief A domain event. Events are notifications, not records: keep Data small and
+//!        let subscribers load details from the database by ID.
+type Event struct {
+	Type   string          `json:"type"`
+	UserID int             `json:"user_id"`
+	ID     int64           `json:"id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+//! \interface Bus
+//! \brief Publishes domain events to the subscribers of every API instance.
+type Bus interface {
+	//! \brief Publishes an event immediately.
+	Publish(ctx context.Context, ev Event) error
+	//! \brief Publishes an event when, and only if, the transaction commits.
+	PublishTx(tx *sql.Tx, ev Event) error
+	//! \brief Subscribes to all events delivered to this instance.
+	Subscribe() *Subscription
+}
+
+//! \struct Subscription
+//! \brief Receives events from a bus. C is closed when the subscriber falls too far
+//!        behind or unsubscribes; the subscriber should then resynchronize.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	fanout *fanout
+}
+
+//! \fn Close()
+//! \brief Ends the subscription.
+func (s *Subscription) Close() {
+	s.fanout.remove(s)
+}
+
+//! \struct fanout
+//! \brief Delivers events to the local subscribers of a bus.
+type fanout struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+//! \fn Subscribe() *Subscription
+//! \brief Registers a local subscriber.
+//! \return New subscription.
+func (f *fanout) Subscribe() *Subscription {
+	ch := make(chan Event, subscriptionBuffer)
+	sub := &Subscription{C: ch, ch: ch, fanout: f}
+	f.mu.Lock()
+	if f.subs == nil {
+		f.subs = map[*Subscription]struct{}{}
+	}
+	f.subs[sub] = struct{}{}
+	f.mu.Unlock()
+	return sub
+}
+
+//! \fn remove(sub *Subscription)
+//! \brief Unregisters a subscriber and closes its channel, once.
+//! \param sub Subscription to remove.
+func (f *fanout) remove(sub *Subscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[sub]; ok {
+		delete(f.subs, sub)
+		close(sub.ch)
+	}
+}
+
+//! \fn deliver(ev Event)
+//! \brief Hands an event to every subscriber, closing the subscriptions that cannot keep up.
+//! \param ev Event to deliver.
+func (f *fanout) deliver(ev Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs {
+		select {
+		case sub.ch <- ev:
+		default:
+			delete(f.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
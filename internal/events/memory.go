@@ -0,0 +1,40 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+)
+
+//! \struct Memory
+//! \brief In-process bus for tests and single-instance setups. PublishTx delivers
+//!        immediately, since database/sql offers no commit hook.
+type Memory struct {
+	fanout
+}
+
+//! \fn NewMemory() *Memory
+//! \brief Initializes an in-memory bus.
+//! \return Pointer to initialized Memory.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+//! \fn Publish(ctx context.Context, ev Event) error
+//! \brief Delivers an event to the local subscribers.
+//! \param ctx Unused.
+//! \param ev Event to publish.
+//! \return Always nil.
+func (m *Memory) Publish(ctx context.Context, ev Event) error {
+	m.deliver(ev)
+	return nil
+}
+
+//! \fn PublishTx(tx *sql.Tx, ev Event) error
+//! \brief Delivers an event to the local subscribers right away.
+//! \param tx Unused.
+//! \param ev Event to publish.
+//! \return Always nil.
+func (m *Memory) PublishTx(tx *sql.Tx, ev Event) error {
+	m.deliver(ev)
+	return nil
+}
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	//! \brief Notification channel shared by all instances.
+	channel = "task_tracker_events"
+	//! \brief Postgres rejects notification payloads of 8000 bytes or more.
+	maxPayload = 7900
+
+	minReconnect = time.Second
+	maxReconnect = time.Minute
+	pingInterval = 90 * time.Second
+)
+
+//! \struct Postgres
+//! \brief Bus built on Postgres NOTIFY/LISTEN. Notifications sent inside a transaction are
+//!        delivered to every listening instance after commit, in commit order.
+type Postgres struct {
+	fanout
+	db       *sql.DB
+	listener *pq.Listener
+	logger   *zap.Logger
+}
+
+//! \fn NewPostgres(dsn string, db *sql.DB, logger *zap.Logger) (*Postgres, error)
+//! \brief Initializes a Postgres bus with a dedicated listening connection.
+//! \param dsn Database URL for the listener connection.
+//! \param db Database connection used to publish.
+//! \param logger Logger instance.
+//! \return Pointer to initialized Postgres and error (if any).
+func NewPostgres(dsn string, db *sql.DB, logger *zap.Logger) (*Postgres, error) {
+	listener := pq.NewListener(dsn, minReconnect, maxReconnect, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			logger.Warn("Event bus disconnected", zap.Error(err))
+		case pq.ListenerEventReconnected:
+			logger.Info("Event bus reconnected")
+		case pq.ListenerEventConnectionAttemptFailed:
+			logger.Warn("Event bus connection attempt failed", zap.Error(err))
+		}
+	})
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &Postgres{db: db, listener: listener, logger: logger}, nil
+}
+
+//! \fn encode(ev Event) (string, error)
+//! \brief Serializes an event into a notification payload.
+//! \param ev Event to encode.
+//! \return Payload and error (if any).
+func encode(ev Event) (string, error) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxPayload {
+		return "", ErrTooLarge
+	}
+	return string(data), nil
+}
+
+//! \fn Publish(ctx context.Context, ev Event) error
+//! \brief Sends an event to all instances.
+//! \param ctx Request context.
+//! \param ev Event to publish.
+//! \return Error (if any).
+func (p *Postgres) Publish(ctx context.Context, ev Event) error {
+	payload, err := encode(ev)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, payload)
+	return err
+}
+
+//! \fn PublishTx(tx *sql.Tx, ev Event) error
+//! \brief Queues an event that Postgres sends to all instances when the transaction commits.
+//! \param tx Open transaction.
+//! \param ev Event to publish.
+//! \return Error (if any).
+func (p *Postgres) PublishTx(tx *sql.Tx, ev Event) error {
+	payload, err := encode(ev)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`SELECT pg_notify($1, $2)`, channel, payload)
+	return err
+}
+
+//! \fn Run(ctx context.Context)
+//! \brief Receives notifications and delivers them to local subscribers until the context is cancelled.
+//!        After the listener reconnects a Reconnected event tells subscribers to resynchronize.
+//! \param ctx Context controlling the bus's lifetime.
+func (p *Postgres) Run(ctx context.Context) {
+	defer p.listener.Close()
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-p.listener.Notify:
+			if n == nil {
+				p.deliver(Event{Type: Reconnected})
+				continue
+			}
+			var ev Event
+			if err := json.Unmarshal([]byte(n.Extra), &ev); err != nil {
+				p.logger.Warn("Ignoring malformed event", zap.Error(err))
+				continue
+			}
+			p.deliver(ev)
+		case <-ping.C:
+			// Detects dead connections that would otherwise go unnoticed while idle.
+			go p.listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package labels
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"task-tracker/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+//! \fn GetLabelsHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list a user's labels.
+//! \param s Label service instance.
+//! \return Gin handler function.
+func GetLabelsHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		labels, err := s.GetLabels(userID.(int))
+		if err != nil {
+			s.logger.Error("Failed to get labels", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, labels)
+	}
+}
+
+//! \fn CreateLabelHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to create a label.
+//! \param s Label service instance.
+//! \return Gin handler function.
+func CreateLabelHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var label models.Label
+		if err := c.ShouldBindJSON(&label); err != nil {
+			s.logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&label); err != nil {
+			s.logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		label.UserID = userID.(int)
+		err := s.CreateLabel(&label)
+		if errors.Is(err, ErrDuplicateName) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create label"})
+			return
+		}
+		c.JSON(http.StatusCreated, label)
+	}
+}
+
+//! \fn UpdateLabelHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to update a label.
+//! \param s Label service instance.
+//! \return Gin handler function.
+func UpdateLabelHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var label models.Label
+		if err := c.ShouldBindJSON(&label); err != nil {
+			s.logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&label); err != nil {
+			s.logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		err := s.UpdateLabel(&label, c.Param("id"), userID.(int))
+		if errors.Is(err, ErrDuplicateName) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update label"})
+			return
+		}
+		c.JSON(http.StatusOK, label)
+	}
+}
+
+//! \fn DeleteLabelHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to delete a label.
+//! \param s Label service instance.
+//! \return Gin handler function.
+func DeleteLabelHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		err := s.DeleteLabel(c.Param("id"), userID.(int))
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Label not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete label"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Label deleted"})
+	}
+}
@@ -0,0 +1,136 @@
+package labels
+
+import (
+	"database/sql"
+	"errors"
+
+	"task-tracker/internal/models"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+//! \var ErrDuplicateName
+//! \brief Returned when a user already has a label with the same name.
+var ErrDuplicateName = errors.New("label name already exists")
+
+//! \struct Service
+//! \brief Handles label-related business logic.
+type Service struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+//! \fn NewService(db *sql.DB, logger *zap.Logger) *Service
+//! \brief Initializes a new label service.
+//! \param db Database connection.
+//! \param logger Logger instance.
+//! \return Pointer to initialized Service.
+func NewService(db *sql.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		logger: logger,
+	}
+}
+
+//! \fn GetLabels(userID int) ([]models.Label, error)
+//! \brief Retrieves a user's labels ordered by name.
+//! \param userID ID of the user.
+//! \return List of labels and error (if any).
+func (s *Service) GetLabels(userID int) ([]models.Label, error) {
+	query := `SELECT id, user_id, name, color, created_at FROM labels WHERE user_id = $1 ORDER BY name`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch labels", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := []models.Label{}
+	for rows.Next() {
+		var label models.Label
+		if err := rows.Scan(&label.ID, &label.UserID, &label.Name, &label.Color, &label.CreatedAt); err != nil {
+			s.logger.Error("Failed to scan label", zap.Error(err))
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate labels", zap.Error(err))
+		return nil, err
+	}
+	return labels, nil
+}
+
+//! \fn CreateLabel(label *models.Label) error
+//! \brief Creates a new label.
+//! \param label Label data; receives its ID and creation time.
+//! \return Error (if any).
+func (s *Service) CreateLabel(label *models.Label) error {
+	query := `INSERT INTO labels (user_id, name, color) VALUES ($1, $2, $3) RETURNING id, created_at`
+	err := s.db.QueryRow(query, label.UserID, label.Name, label.Color).Scan(&label.ID, &label.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicateName
+	}
+	if err != nil {
+		s.logger.Error("Failed to create label", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Label created", zap.Int("label_id", label.ID), zap.Int("user_id", label.UserID))
+	return nil
+}
+
+//! \fn UpdateLabel(label *models.Label, labelID string, userID int) error
+//! \brief Renames or recolors a user's label.
+//! \param label Updated label data; receives the stored values.
+//! \param labelID ID of the label.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) UpdateLabel(label *models.Label, labelID string, userID int) error {
+	query := `UPDATE labels SET name = $1, color = $2 WHERE id = $3 AND user_id = $4
+              RETURNING id, user_id, created_at`
+	err := s.db.QueryRow(query, label.Name, label.Color, labelID, userID).
+		Scan(&label.ID, &label.UserID, &label.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicateName
+	}
+	if err != nil {
+		s.logger.Warn("Label not found", zap.String("label_id", labelID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Label updated", zap.String("label_id", labelID), zap.Int("user_id", userID))
+	return nil
+}
+
+//! \fn DeleteLabel(labelID string, userID int) error
+//! \brief Deletes a user's label and detaches it from all tasks.
+//! \param labelID ID of the label.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) DeleteLabel(labelID string, userID int) error {
+	query := `DELETE FROM labels WHERE id = $1 AND user_id = $2`
+	result, err := s.db.Exec(query, labelID, userID)
+	if err != nil {
+		s.logger.Error("Failed to delete label", zap.Error(err))
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		s.logger.Warn("Label not found", zap.String("label_id", labelID))
+		return sql.ErrNoRows
+	}
+
+	s.logger.Info("Label deleted", zap.String("label_id", labelID), zap.Int("user_id", userID))
+	return nil
+}
+
+//! \fn isUniqueViolation(err error) bool
+//! \brief Reports whether an error is a Postgres unique constraint violation.
+//! \param err Error to inspect.
+//! \return True on unique violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+//! \struct File
+//! \brief Writes emails to .eml files instead of sending them, for local development and tests.
+//!        Without a directory the messages are only logged.
+type File struct {
+	dir    string
+	from   string
+	logger *zap.Logger
+}
+
+//! \fn NewFile(dir, from string, logger *zap.Logger) (*File, error)
+//! \brief Initializes the file sender, creating the directory if needed.
+//! \param dir Directory receiving the messages; empty to only log them.
+//! \param from Sender address.
+//! \param logger Logger instance.
+//! \return Pointer to initialized File and error (if any).
+func NewFile(dir, from string, logger *zap.Logger) (*File, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create mail directory: %w", err)
+		}
+	}
+	return &File{dir: dir, from: from, logger: logger}, nil
+}
+
+//! \fn Send(ctx context.Context, msg Message) error
+//! \brief Logs a message and stores it as a file.
+//! \param ctx Request context.
+//! \param msg Message to send.
+//! \return Error (if any).
+func (f *File) Send(ctx context.Context, msg Message) error {
+	if f.dir == "" {
+		f.logger.Info("Email", zap.String("to", msg.To), zap.String("subject", msg.Subject),
+			zap.String("body", msg.Body))
+		return nil
+	}
+
+	now := time.Now()
+	data, err := render(f.from, msg, now)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.dir, ".mail-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// Time-ordered names, made unique by the temporary file's random suffix.
+	suffix := strings.TrimPrefix(filepath.Base(tmp.Name()), ".mail-")
+	path := filepath.Join(f.dir, now.UTC().Format("20060102T150405.000000000")+"-"+suffix+".eml")
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	f.logger.Info("Email written", zap.String("to", msg.To), zap.String("subject", msg.Subject),
+		zap.String("path", path))
+	return nil
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"time"
+)
+
+//! \struct Message
+//! \brief A plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+//! \interface Sender
+//! \brief Delivers emails.
+type Sender interface {
+	//! \brief Sends a message; an error means it was not accepted for delivery.
+	Send(ctx context.Context, msg Message) error
+}
+
+//! \fn render(from string, msg Message, now time.Time) ([]byte, error)
+//! \brief Formats a message as RFC 5322 text with a quoted-printable UTF-8 body.
+//! \param from Sender address.
+//! \param msg Message to format.
+//! \param now Time used for the Date header.
+//! \return Message text with CRLF line endings and error (if any).
+func render(from string, msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write(bytes.ReplaceAll([]byte(msg.Body), []byte("\n"), []byte("\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
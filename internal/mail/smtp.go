@@ -0,0 +1,125 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+//! \struct SMTP
+//! \brief Sends emails through an SMTP relay. Port 465 uses implicit TLS; other ports upgrade
+//!        with STARTTLS when the server offers it, which is required before authenticating.
+type SMTP struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+//! \fn NewSMTP(host, port, username, password, from string) (*SMTP, error)
+//! \brief Initializes the SMTP sender.
+//! \param host Relay host name.
+//! \param port Relay port, 587 if empty.
+//! \param username User name; empty for relays without authentication.
+//! \param password Password.
+//! \param from Sender address, e.g. "Task Tracker <no-reply@example.com>".
+//! \return Pointer to initialized SMTP and error (if any).
+func NewSMTP(host, port, username, password, from string) (*SMTP, error) {
+	if host == "" {
+		return nil, fmt.Errorf("smtp host is required")
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("invalid sender address %q", from)
+	}
+	if port == "" {
+		port = "587"
+	}
+	return &SMTP{host: host, port: port, username: username, password: password, from: from}, nil
+}
+
+//! \fn dial(ctx context.Context) (*smtp.Client, error)
+//! \brief Connects to the relay and secures the connection.
+//! \param ctx Request context; its deadline bounds the whole exchange.
+//! \return SMTP client and error (if any).
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.host, s.port)
+	tlsConfig := &tls.Config{ServerName: s.host}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if s.port == "465" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok && s.port != "465" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+//! \fn Send(ctx context.Context, msg Message) error
+//! \brief Hands a message to the relay.
+//! \param ctx Request context.
+//! \param msg Message to send.
+//! \return Error (if any).
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	from, _ := mail.ParseAddress(s.from)
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+	data, err := render(s.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted connection to a remote host.
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
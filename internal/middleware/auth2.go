@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
 
 	"task-tracker/internal/auth"
@@ -9,7 +10,7 @@ import (
 )
 
 //! \fn AuthMiddleware(s *auth.Service) gin.HandlerFunc
-//! \brief Verifies JWT token in request headers.
+//! \brief Verifies JWT token in request headers and rejects tokens of revoked sessions.
 //! \param s Authentication service instance.
 //! \return Gin middleware function.
 func AuthMiddleware(s *auth.Service) gin.HandlerFunc {
@@ -34,8 +35,85 @@ func AuthMiddleware(s *auth.Service) gin.HandlerFunc {
 			return
 		}
 
+		// Tokens without a session predate session tracking and expire within minutes.
+		if claims.SessionID != "" {
+			active, err := s.SessionActive(claims.SessionID)
+			if err != nil {
+				c.JSON(500, gin.H{"error": "Internal server error"})
+				c.Abort()
+				return
+			}
+			if !active {
+				s.Logger.Warn("Token of revoked session", zap.Int("user_id", claims.UserID))
+				c.JSON(401, gin.H{"error": "Session revoked"})
+				c.Abort()
+				return
+			}
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("session_id", claims.SessionID)
+		c.Next()
+	}
+}
+
+//! \fn StreamAuthMiddleware(s *auth.Service) gin.HandlerFunc
+//! \brief Authenticates a streaming request. Browsers' EventSource cannot set headers, so
+//!        instead of the JWT token the request may carry a single-use ticket query parameter.
+//! \param s Authentication service instance.
+//! \return Gin middleware function.
+func StreamAuthMiddleware(s *auth.Service) gin.HandlerFunc {
+	verify := AuthMiddleware(s)
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" || c.GetHeader("Authorization") != "" {
+			verify(c)
+			return
+		}
+
+		claims, err := s.RedeemStreamTicket(ticket)
+		if errors.Is(err, auth.ErrInvalidStreamTicket) {
+			c.JSON(401, gin.H{"error": "Invalid stream ticket"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Internal server error"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Set("session_id", claims.SessionID)
+		c.Next()
+	}
+}
+
+//! \fn VerifiedEmailMiddleware(s *auth.Service, required bool) gin.HandlerFunc
+//! \brief Rejects requests of users whose email address is not verified. Runs after AuthMiddleware.
+//! \param s Authentication service instance.
+//! \param required Whether verification is enforced; if not, requests pass through.
+//! \return Gin middleware function.
+func VerifiedEmailMiddleware(s *auth.Service, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !required {
+			c.Next()
+			return
+		}
+
+		verified, err := s.EmailVerified(c.GetInt("user_id"))
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Internal server error"})
+			c.Abort()
+			return
+		}
+		if !verified {
+			c.JSON(403, gin.H{"error": "Please verify your email address first"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
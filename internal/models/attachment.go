@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+//! \struct Attachment
+//! \brief Represents a file attached to a task; the content lives in blob storage.
+type Attachment struct {
+	ID          int       `json:"id"`
+	TaskID      int       `json:"task_id"`
+	UploaderID  int       `json:"uploader_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
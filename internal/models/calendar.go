@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+//! \struct CalendarFeed
+//! \brief Represents a user's secret iCalendar feed. The token is only known right after it is generated.
+type CalendarFeed struct {
+	Token      string     `json:"token,omitempty"`
+	URL        string     `json:"url,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+//! \struct Comment
+//! \brief Represents a comment in a task's discussion thread.
+type Comment struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"task_id"`
+	AuthorID  int        `json:"author_id"`
+	Body      string     `json:"body" validate:"required,max=10000"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at"`
+}
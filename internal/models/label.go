@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+//! \struct Label
+//! \brief Represents a user-defined label that can be attached to tasks.
+type Label struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name" validate:"required,max=50"`
+	Color     string    `json:"color" validate:"required,hexcolor"`
+	CreatedAt time.Time `json:"created_at"`
+}
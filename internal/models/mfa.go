@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+//! \struct MFAStatus
+//! \brief Describes a user's two-factor authentication settings.
+type MFAStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+//! \struct TOTPEnrollment
+//! \brief A TOTP secret awaiting confirmation, shown once to be added to an authenticator app.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+//! \struct Project
+//! \brief Represents a list grouping a user's tasks.
+type Project struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name" validate:"required,max=100"`
+	IsInbox    bool       `json:"is_inbox"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+//! \struct Reminder
+//! \brief Represents a reminder for a task, either relative to its due date ("before")
+//!        or at an absolute time ("remind_at").
+type Reminder struct {
+	ID         int        `json:"id"`
+	TaskID     int        `json:"task_id"`
+	UserID     int        `json:"user_id"`
+	Before     string     `json:"before,omitempty"`
+	RemindAt   *time.Time `json:"remind_at"`
+	Channel    string     `json:"channel" validate:"omitempty,oneof=in_app webhook"`
+	WebhookURL string     `json:"webhook_url,omitempty" validate:"required_if=Channel webhook,omitempty,url"`
+	Secret     string     `json:"secret,omitempty"`
+	SentAt     *time.Time `json:"sent_at"`
+	LastError  *string    `json:"last_error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+//! \struct Notification
+//! \brief Represents an in-app notification produced by a delivered reminder.
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	TaskID    *int       `json:"task_id"`
+	Message   string     `json:"message"`
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at"`
+}
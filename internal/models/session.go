@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+//! \struct Session
+//! \brief Represents a login of a user on one device, i.e. a refresh token family.
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
@@ -7,12 +7,21 @@ import (
 //! \struct Task
 //! \brief Represents a task in the system.
 type Task struct {
-	ID          int       `json:"id"`
-    UserID      int       `json:"user_id"`
-    Title       string    `json:"title" validate:"required"`
-    Description string    `json:"description"`
-    Status      string    `json:"status" validate:"required,oneof=pending done in_progress"`
-    Priority    int       `json:"priority" validate:"gte=1"`
-    DueDate     time.Time `json:"due_date"`
-    CreatedAt   time.Time `json:"created_at"`
+	ID          int        `json:"id"`
+    UserID      int        `json:"user_id"`
+    Title       string     `json:"title" validate:"required"`
+    Description string     `json:"description"`
+    Status      string     `json:"status" validate:"required,oneof=pending done in_progress"`
+    Priority    int        `json:"priority" validate:"gte=1"`
+    DueDate     *time.Time `json:"due_date"`
+    CreatedAt   time.Time  `json:"created_at"`
+    Version     int        `json:"version"`
+    DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+    ParentID    *int       `json:"parent_id"`
+    ProjectID   int        `json:"project_id"`
+    Recurrence  *string    `json:"recurrence,omitempty"`
+    Progress    *int       `json:"progress,omitempty"`
+    BlockedBy   []TaskRef  `json:"blocked_by,omitempty"`
+    Blocks      []TaskRef  `json:"blocks,omitempty"`
+    Labels      []Label    `json:"labels,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+//! \struct FieldChange
+//! \brief Records the previous and new value of a single task field.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+//! \struct TaskEvent
+//! \brief Represents an entry in a task's change history.
+type TaskEvent struct {
+	ID        int64                  `json:"id"`
+	TaskID    int                    `json:"task_id"`
+	ActorID   *int                   `json:"actor_id"`
+	Action    string                 `json:"action"`
+	Changes   map[string]FieldChange `json:"changes"`
+	CreatedAt time.Time              `json:"created_at"`
+}
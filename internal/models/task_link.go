@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+//! \struct TaskLink
+//! \brief Represents a typed relation from one task to another.
+type TaskLink struct {
+	ID        int       `json:"id"`
+	SourceID  int       `json:"source_id"`
+	TargetID  int       `json:"target_id" validate:"required"`
+	Type      string    `json:"type" validate:"required,oneof=blocks relates_to duplicate_of"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+//! \struct TaskRef
+//! \brief Summarizes a related task.
+type TaskRef struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+//! \struct Webhook
+//! \brief Represents an endpoint that receives signed task events.
+type Webhook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	URL       string    `json:"url" validate:"required,url"`
+	Events    []string  `json:"events" validate:"required,min=1,dive,oneof=task.created task.updated task.deleted"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+//! \struct WebhookDelivery
+//! \brief Represents one attempt series to deliver an event to a webhook.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
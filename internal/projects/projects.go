@@ -0,0 +1,151 @@
+package projects
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"task-tracker/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+//! \fn GetProjectsHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list a user's projects; archived=true includes archived ones.
+//! \param s Project service instance.
+//! \return Gin handler function.
+func GetProjectsHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		projects, err := s.GetProjects(userID.(int), c.Query("archived") == "true")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, projects)
+	}
+}
+
+//! \fn GetProjectHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to retrieve a specific project.
+//! \param s Project service instance.
+//! \return Gin handler function.
+func GetProjectHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		project, err := s.GetProject(c.Param("id"), userID.(int))
+		if err != nil {
+			respondError(c, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, project)
+	}
+}
+
+//! \fn CreateProjectHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to create a project.
+//! \param s Project service instance.
+//! \return Gin handler function.
+func CreateProjectHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var project models.Project
+		if err := c.ShouldBindJSON(&project); err != nil {
+			s.logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&project); err != nil {
+			s.logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		project.UserID = userID.(int)
+		if err := s.CreateProject(&project); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+			return
+		}
+		c.JSON(http.StatusCreated, project)
+	}
+}
+
+//! \fn UpdateProjectHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to rename a project.
+//! \param s Project service instance.
+//! \return Gin handler function.
+func UpdateProjectHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var project models.Project
+		if err := c.ShouldBindJSON(&project); err != nil {
+			s.logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&project); err != nil {
+			s.logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		if err := s.RenameProject(&project, c.Param("id"), userID.(int)); err != nil {
+			respondError(c, err, "Failed to update project")
+			return
+		}
+		c.JSON(http.StatusOK, project)
+	}
+}
+
+//! \fn ArchiveProjectHandler(s *Service, archived bool) gin.HandlerFunc
+//! \brief Creates a Gin handler to archive or unarchive a project.
+//! \param s Project service instance.
+//! \param archived True for the archive handler, false for unarchive.
+//! \return Gin handler function.
+func ArchiveProjectHandler(s *Service, archived bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		project, err := s.SetArchived(c.Param("id"), userID.(int), archived)
+		if err != nil {
+			respondError(c, err, "Failed to archive project")
+			return
+		}
+		c.JSON(http.StatusOK, project)
+	}
+}
+
+//! \fn DeleteProjectHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to delete a project, moving its tasks to the Inbox.
+//! \param s Project service instance.
+//! \return Gin handler function.
+func DeleteProjectHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		if err := s.DeleteProject(c.Param("id"), userID.(int)); err != nil {
+			respondError(c, err, "Failed to delete project")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Project deleted"})
+	}
+}
+
+//! \fn respondError(c *gin.Context, err error, message string)
+//! \brief Writes the response for a failed project operation.
+//! \param c Gin context.
+//! \param err Error returned by the service.
+//! \param message Message used for unexpected errors.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	case errors.Is(err, ErrInboxImmutable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
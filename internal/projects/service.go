@@ -0,0 +1,247 @@
+package projects
+
+import (
+	"database/sql"
+	"errors"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \const InboxName
+//! \brief Name of the default project every user's tasks land in.
+const InboxName = "Inbox"
+
+//! \var ErrInboxImmutable
+//! \brief Returned when trying to archive or delete a user's Inbox.
+var ErrInboxImmutable = errors.New("the Inbox project cannot be archived or deleted")
+
+//! \interface queryRower
+//! \brief Common interface of *sql.DB and *sql.Tx used for single-row queries.
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+//! \interface TaskMover
+//! \brief Moves the tasks of a project through the task service, which owns their history and events.
+type TaskMover interface {
+	MoveProjectTasks(tx *sql.Tx, fromID, toID, userID int) error
+}
+
+//! \struct Service
+//! \brief Handles project-related business logic.
+type Service struct {
+	db     *sql.DB
+	tasks  TaskMover
+	logger *zap.Logger
+}
+
+//! \fn NewService(db *sql.DB, tasks TaskMover, logger *zap.Logger) *Service
+//! \brief Initializes a new project service.
+//! \param db Database connection.
+//! \param tasks Task service moving the tasks of deleted projects.
+//! \param logger Logger instance.
+//! \return Pointer to initialized Service.
+func NewService(db *sql.DB, tasks TaskMover, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		tasks:  tasks,
+		logger: logger,
+	}
+}
+
+//! \fn EnsureInbox(q queryRower, userID int) (int, error)
+//! \brief Returns the ID of a user's Inbox project, creating it if needed.
+//! \param q Database connection or open transaction.
+//! \param userID ID of the user.
+//! \return Inbox project ID and error (if any).
+func EnsureInbox(q queryRower, userID int) (int, error) {
+	query := `WITH created AS (
+                  INSERT INTO projects (user_id, name, is_inbox) VALUES ($1, $2, TRUE)
+                  ON CONFLICT (user_id) WHERE is_inbox DO NOTHING RETURNING id
+              )
+              SELECT id FROM created
+              UNION ALL
+              SELECT id FROM projects WHERE user_id = $1 AND is_inbox
+              LIMIT 1`
+	var id int
+	err := q.QueryRow(query, userID, InboxName).Scan(&id)
+	return id, err
+}
+
+//! \const projectColumns
+//! \brief Column list matching the scan order of scanProject.
+const projectColumns = `id, user_id, name, is_inbox, archived_at, created_at`
+
+//! \interface rowScanner
+//! \brief Common interface of *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//! \fn scanProject(row rowScanner, p *models.Project) error
+//! \brief Scans the projectColumns of a row into a project.
+//! \param row Row to scan.
+//! \param p Destination project.
+//! \return Error (if any).
+func scanProject(row rowScanner, p *models.Project) error {
+	return row.Scan(&p.ID, &p.UserID, &p.Name, &p.IsInbox, &p.ArchivedAt, &p.CreatedAt)
+}
+
+//! \fn GetProjects(userID int, includeArchived bool) ([]models.Project, error)
+//! \brief Retrieves a user's projects, Inbox first.
+//! \param userID ID of the user.
+//! \param includeArchived Whether archived projects are listed.
+//! \return List of projects and error (if any).
+func (s *Service) GetProjects(userID int, includeArchived bool) ([]models.Project, error) {
+	if _, err := EnsureInbox(s.db, userID); err != nil {
+		s.logger.Error("Failed to ensure Inbox project", zap.Error(err))
+		return nil, err
+	}
+
+	query := `SELECT ` + projectColumns + ` FROM projects
+              WHERE user_id = $1 AND ($2 OR archived_at IS NULL)
+              ORDER BY is_inbox DESC, name`
+	rows, err := s.db.Query(query, userID, includeArchived)
+	if err != nil {
+		s.logger.Error("Failed to fetch projects", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		var project models.Project
+		if err := scanProject(rows, &project); err != nil {
+			s.logger.Error("Failed to scan project", zap.Error(err))
+			return nil, err
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate projects", zap.Error(err))
+		return nil, err
+	}
+	return projects, nil
+}
+
+//! \fn GetProject(projectID string, userID int) (*models.Project, error)
+//! \brief Retrieves a specific project by ID for a user.
+//! \param projectID ID of the project.
+//! \param userID ID of the user.
+//! \return Project and error (if any).
+func (s *Service) GetProject(projectID string, userID int) (*models.Project, error) {
+	var project models.Project
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1 AND user_id = $2`
+	if err := scanProject(s.db.QueryRow(query, projectID, userID), &project); err != nil {
+		s.logger.Warn("Project not found", zap.String("project_id", projectID), zap.Error(err))
+		return nil, err
+	}
+	return &project, nil
+}
+
+//! \fn CreateProject(project *models.Project) error
+//! \brief Creates a new project.
+//! \param project Project data; receives its stored values.
+//! \return Error (if any).
+func (s *Service) CreateProject(project *models.Project) error {
+	query := `INSERT INTO projects (user_id, name) VALUES ($1, $2) RETURNING ` + projectColumns
+	if err := scanProject(s.db.QueryRow(query, project.UserID, project.Name), project); err != nil {
+		s.logger.Error("Failed to create project", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Project created", zap.Int("project_id", project.ID), zap.Int("user_id", project.UserID))
+	return nil
+}
+
+//! \fn RenameProject(project *models.Project, projectID string, userID int) error
+//! \brief Renames a user's project.
+//! \param project Updated project data; receives its stored values.
+//! \param projectID ID of the project.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) RenameProject(project *models.Project, projectID string, userID int) error {
+	query := `UPDATE projects SET name = $1 WHERE id = $2 AND user_id = $3 RETURNING ` + projectColumns
+	if err := scanProject(s.db.QueryRow(query, project.Name, projectID, userID), project); err != nil {
+		s.logger.Warn("Project not found", zap.String("project_id", projectID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Project renamed", zap.String("project_id", projectID), zap.Int("user_id", userID))
+	return nil
+}
+
+//! \fn SetArchived(projectID string, userID int, archived bool) (*models.Project, error)
+//! \brief Archives or unarchives a user's project; tasks of archived projects leave the default listing.
+//! \param projectID ID of the project.
+//! \param userID ID of the user.
+//! \param archived True to archive, false to unarchive.
+//! \return Updated project and error (if any).
+func (s *Service) SetArchived(projectID string, userID int, archived bool) (*models.Project, error) {
+	current, err := s.GetProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current.IsInbox {
+		return nil, ErrInboxImmutable
+	}
+
+	var project models.Project
+	query := `UPDATE projects SET archived_at = CASE WHEN $1 THEN COALESCE(archived_at, CURRENT_TIMESTAMP) END
+              WHERE id = $2 AND user_id = $3 RETURNING ` + projectColumns
+	if err := scanProject(s.db.QueryRow(query, archived, projectID, userID), &project); err != nil {
+		s.logger.Error("Failed to archive project", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Project archive state changed", zap.String("project_id", projectID), zap.Bool("archived", archived))
+	return &project, nil
+}
+
+//! \fn DeleteProject(projectID string, userID int) error
+//! \brief Deletes a user's project, moving its tasks to the Inbox. Trashed tasks move too,
+//!        so they can still be restored.
+//! \param projectID ID of the project.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) DeleteProject(projectID string, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	var isInbox bool
+	query := `SELECT id, is_inbox FROM projects WHERE id = $1 AND user_id = $2 FOR UPDATE`
+	if err := tx.QueryRow(query, projectID, userID).Scan(&id, &isInbox); err != nil {
+		s.logger.Warn("Project not found", zap.String("project_id", projectID), zap.Error(err))
+		return err
+	}
+	if isInbox {
+		return ErrInboxImmutable
+	}
+
+	inboxID, err := EnsureInbox(tx, userID)
+	if err != nil {
+		s.logger.Error("Failed to ensure Inbox project", zap.Error(err))
+		return err
+	}
+	if err := s.tasks.MoveProjectTasks(tx, id, inboxID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM projects WHERE id = $1`, id); err != nil {
+		s.logger.Error("Failed to delete project", zap.Error(err))
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Project deleted", zap.String("project_id", projectID), zap.Int("user_id", userID))
+	return nil
+}
@@ -0,0 +1,124 @@
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"task-tracker/internal/models"
+	"task-tracker/internal/safehttp"
+	"task-tracker/internal/signing"
+	"go.uber.org/zap"
+)
+
+//! \struct Delivery
+//! \brief A due reminder together with the task it is about.
+type Delivery struct {
+	Reminder  models.Reminder
+	Secret    string
+	TaskTitle string
+	DueDate   *time.Time
+}
+
+//! \fn Message() string
+//! \brief Formats the human-readable reminder text.
+//! \return Reminder message.
+func (d Delivery) Message() string {
+	if d.DueDate == nil {
+		return fmt.Sprintf("Reminder: %q", d.TaskTitle)
+	}
+	return fmt.Sprintf("Reminder: %q is due at %s", d.TaskTitle, d.DueDate.Format(time.RFC3339))
+}
+
+//! \interface Notifier
+//! \brief Delivers reminders over one channel.
+type Notifier interface {
+	//! \brief Delivers a reminder; an error makes the scheduler retry later.
+	Notify(ctx context.Context, d Delivery) error
+}
+
+//! \struct InAppNotifier
+//! \brief Logs reminders and stores them as in-app notifications.
+type InAppNotifier struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+//! \fn NewInAppNotifier(db *sql.DB, logger *zap.Logger) *InAppNotifier
+//! \brief Initializes the in-app channel.
+//! \param db Database connection.
+//! \param logger Logger instance.
+//! \return Pointer to initialized InAppNotifier.
+func NewInAppNotifier(db *sql.DB, logger *zap.Logger) *InAppNotifier {
+	return &InAppNotifier{db: db, logger: logger}
+}
+
+//! \fn Notify(ctx context.Context, d Delivery) error
+//! \brief Records a notification for the reminder's user.
+//! \param ctx Delivery context.
+//! \param d Reminder to deliver.
+//! \return Error (if any).
+func (n *InAppNotifier) Notify(ctx context.Context, d Delivery) error {
+	query := `INSERT INTO notifications (user_id, task_id, message) VALUES ($1, $2, $3)`
+	if _, err := n.db.ExecContext(ctx, query, d.Reminder.UserID, d.Reminder.TaskID, d.Message()); err != nil {
+		return err
+	}
+	n.logger.Info("Reminder delivered", zap.Int("reminder_id", d.Reminder.ID),
+		zap.Int("task_id", d.Reminder.TaskID), zap.Int("user_id", d.Reminder.UserID))
+	return nil
+}
+
+//! \struct WebhookNotifier
+//! \brief Posts reminders as JSON to the reminder's webhook URL, signed like webhook deliveries.
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+//! \fn NewWebhookNotifier() *WebhookNotifier
+//! \brief Initializes the webhook channel; it cannot reach internal addresses.
+//! \return Pointer to initialized WebhookNotifier.
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{client: safehttp.NewClient(10 * time.Second)}
+}
+
+//! \fn Notify(ctx context.Context, d Delivery) error
+//! \brief Posts the reminder; any non-2xx response counts as a failure.
+//! \param ctx Delivery context.
+//! \param d Reminder to deliver.
+//! \return Error (if any).
+func (n *WebhookNotifier) Notify(ctx context.Context, d Delivery) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":        "task.reminder",
+		"reminder_id": d.Reminder.ID,
+		"task_id":     d.Reminder.TaskID,
+		"title":       d.TaskTitle,
+		"due_date":    d.DueDate,
+		"message":     d.Message(),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Reminder.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", "task.reminder")
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", signing.Sign(d.Secret, timestamp, payload))
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
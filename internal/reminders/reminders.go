@@ -0,0 +1,125 @@
+package reminders
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"task-tracker/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+//! \fn GetRemindersHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list a task's reminders.
+//! \param s Reminder service instance.
+//! \return Gin handler function.
+func GetRemindersHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		reminders, err := s.GetReminders(c.Param("id"), userID.(int))
+		if err != nil {
+			respondError(c, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, reminders)
+	}
+}
+
+//! \fn AddReminderHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to add a reminder to a task.
+//! \param s Reminder service instance.
+//! \return Gin handler function.
+func AddReminderHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reminder models.Reminder
+		if err := c.ShouldBindJSON(&reminder); err != nil {
+			s.logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&reminder); err != nil {
+			s.logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		if err := s.AddReminder(c.Param("id"), userID.(int), &reminder); err != nil {
+			respondError(c, err, "Failed to add reminder")
+			return
+		}
+		c.JSON(http.StatusCreated, reminder)
+	}
+}
+
+//! \fn DeleteReminderHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to delete a reminder.
+//! \param s Reminder service instance.
+//! \return Gin handler function.
+func DeleteReminderHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		if err := s.DeleteReminder(c.Param("id"), c.Param("reminder_id"), userID.(int)); err != nil {
+			respondError(c, err, "Failed to delete reminder")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Reminder deleted"})
+	}
+}
+
+//! \fn GetNotificationsHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list the user's in-app notifications.
+//! \param s Reminder service instance.
+//! \return Gin handler function.
+func GetNotificationsHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		notifications, err := s.GetNotifications(userID.(int), c.Query("unread") == "true")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, notifications)
+	}
+}
+
+//! \fn MarkReadHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to mark a notification as read.
+//! \param s Reminder service instance.
+//! \return Gin handler function.
+func MarkReadHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		if err := s.MarkRead(c.Param("id"), userID.(int)); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+	}
+}
+
+//! \fn respondError(c *gin.Context, err error, message string)
+//! \brief Writes the response for a failed reminder operation.
+//! \param c Gin context.
+//! \param err Error returned by the service.
+//! \param message Message used for unexpected errors.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reminder not found"})
+	case errors.Is(err, ErrInvalidReminder), errors.Is(err, ErrNoDueDate), errors.Is(err, ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,119 @@
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	claimBatchSize = 50
+	claimLease     = 2 * time.Minute
+	maxAttempts    = 5
+	retryBackoff   = time.Minute
+)
+
+//! \fn claimDue(ctx context.Context, now time.Time) ([]Delivery, error)
+//! \brief Leases a batch of due reminders of open, live tasks. SKIP LOCKED and the lease keep
+//!        concurrent schedulers on other instances from claiming the same reminders, and an
+//!        instance that dies mid-delivery only delays them until the lease runs out.
+//! \param ctx Context controlling the query.
+//! \param now Current time in UTC.
+//! \return Claimed reminders and error (if any).
+func (s *Service) claimDue(ctx context.Context, now time.Time) ([]Delivery, error) {
+	query := `WITH claimed AS (
+                  UPDATE task_reminders SET locked_until = $2, attempts = attempts + 1
+                  WHERE id IN (SELECT r.id FROM task_reminders r JOIN tasks t ON t.id = r.task_id
+                               WHERE r.sent_at IS NULL AND r.remind_at <= $1
+                                 AND (r.locked_until IS NULL OR r.locked_until < $1)
+                                 AND t.deleted_at IS NULL AND t.status <> 'done'
+                               ORDER BY r.remind_at LIMIT $3
+                               FOR UPDATE OF r SKIP LOCKED)
+                  RETURNING ` + reminderColumns + `, COALESCE(webhook_secret, '')
+              )
+              SELECT claimed.*, t.title, t.due_date FROM claimed JOIN tasks t ON t.id = claimed.task_id`
+	rows, err := s.db.QueryContext(ctx, query, now, now.Add(claimLease), claimBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		if err := scanReminder(rows, &d.Reminder, &d.Secret, &d.TaskTitle, &d.DueDate); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+//! \fn deliver(ctx context.Context, d Delivery)
+//! \brief Sends a claimed reminder and records the outcome.
+//! \param ctx Context controlling the delivery.
+//! \param d Reminder to deliver.
+func (s *Service) deliver(ctx context.Context, d Delivery) {
+	notifier, ok := s.notifiers[d.Reminder.Channel]
+	var err error
+	if !ok {
+		err = fmt.Errorf("unknown channel %q", d.Reminder.Channel)
+	} else {
+		err = notifier.Notify(ctx, d)
+	}
+
+	if err == nil {
+		_, err = s.db.ExecContext(ctx, `UPDATE task_reminders SET sent_at = $2, locked_until = NULL, last_error = NULL
+                                        WHERE id = $1`, d.Reminder.ID, time.Now().UTC())
+		if err != nil {
+			s.logger.Error("Failed to mark reminder as sent", zap.Int("reminder_id", d.Reminder.ID), zap.Error(err))
+		}
+		return
+	}
+
+	// Failed deliveries are retried after a growing delay and given up after maxAttempts.
+	s.logger.Warn("Reminder delivery failed", zap.Int("reminder_id", d.Reminder.ID), zap.Error(err))
+	query := `UPDATE task_reminders
+              SET last_error = $2,
+                  locked_until = $3::timestamp + attempts * $4 * INTERVAL '1 second',
+                  sent_at = CASE WHEN attempts >= $5 THEN $3 ELSE NULL END
+              WHERE id = $1`
+	if _, err := s.db.ExecContext(ctx, query, d.Reminder.ID, err.Error(), time.Now().UTC(),
+		int(retryBackoff/time.Second), maxAttempts); err != nil {
+		s.logger.Error("Failed to record reminder failure", zap.Int("reminder_id", d.Reminder.ID), zap.Error(err))
+	}
+}
+
+//! \fn RunScheduler(ctx context.Context, interval time.Duration)
+//! \brief Periodically delivers due reminders until the context is cancelled.
+//!        Safe to run on every API instance at once.
+//! \param ctx Context controlling the scheduler's lifetime.
+//! \param interval How often to look for due reminders.
+func (s *Service) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for {
+			deliveries, err := s.claimDue(ctx, time.Now().UTC())
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.Error("Failed to claim due reminders", zap.Error(err))
+				}
+				break
+			}
+			for _, d := range deliveries {
+				s.deliver(ctx, d)
+			}
+			if len(deliveries) < claimBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package reminders
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"task-tracker/internal/models"
+	"task-tracker/internal/safehttp"
+	"task-tracker/internal/signing"
+	"go.uber.org/zap"
+)
+
+//! \brief Delivery channels of a reminder.
+const (
+	ChannelInApp   = "in_app"
+	ChannelWebhook = "webhook"
+)
+
+//! \var ErrTaskNotFound
+//! \brief Returned when the task does not exist, is trashed or belongs to another user.
+var ErrTaskNotFound = errors.New("task not found")
+
+//! \var ErrInvalidReminder
+//! \brief Returned when a reminder sets neither or both of "before" and "remind_at", or an invalid offset or URL.
+var ErrInvalidReminder = errors.New("reminder needs either a non-negative \"before\" duration or \"remind_at\"")
+
+//! \var ErrNoDueDate
+//! \brief Returned when a relative reminder is added to a task without a due date.
+var ErrNoDueDate = errors.New("task has no due_date to remind relative to")
+
+//! \var ErrInvalidWebhook
+//! \brief Returned when a webhook reminder has no usable http(s) URL of a public host.
+var ErrInvalidWebhook = errors.New("webhook_url must be an http or https URL of a public host")
+
+//! \const reminderColumns
+//! \brief Column list matching the scan order of scanReminder.
+const reminderColumns = `id, task_id, user_id, offset_seconds, remind_at, channel, COALESCE(webhook_url, ''),
+                         sent_at, last_error, created_at`
+
+//! \struct Service
+//! \brief Handles reminder and in-app notification business logic.
+type Service struct {
+	db        *sql.DB
+	notifiers map[string]Notifier
+	logger    *zap.Logger
+}
+
+//! \fn NewService(db *sql.DB, logger *zap.Logger) *Service
+//! \brief Initializes a new reminder service with the in-app and webhook channels.
+//! \param db Database connection.
+//! \param logger Logger instance.
+//! \return Pointer to initialized Service.
+func NewService(db *sql.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db: db,
+		notifiers: map[string]Notifier{
+			ChannelInApp:   NewInAppNotifier(db, logger),
+			ChannelWebhook: NewWebhookNotifier(),
+		},
+		logger: logger,
+	}
+}
+
+//! \interface rowScanner
+//! \brief Common interface of *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//! \fn scanReminder(row rowScanner, r *models.Reminder, extra ...interface{}) error
+//! \brief Scans the reminderColumns of a row into a reminder, followed by any extra columns.
+//! \param row Row to scan.
+//! \param r Destination reminder.
+//! \param extra Destinations of columns selected after reminderColumns.
+//! \return Error (if any).
+func scanReminder(row rowScanner, r *models.Reminder, extra ...interface{}) error {
+	var offset sql.NullInt64
+	dest := []interface{}{&r.ID, &r.TaskID, &r.UserID, &offset, &r.RemindAt, &r.Channel, &r.WebhookURL,
+		&r.SentAt, &r.LastError, &r.CreatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	if offset.Valid {
+		r.Before = (time.Duration(offset.Int64) * time.Second).String()
+	}
+	return nil
+}
+
+//! \fn taskDueDate(taskID string, userID int) (time.Time, error)
+//! \brief Loads the due date of a task the user may access, following the task service's ownership rules.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \return Due date (zero if unset) and ErrTaskNotFound or another error (if any).
+func (s *Service) taskDueDate(taskID string, userID int) (time.Time, error) {
+	var due sql.NullTime
+	query := `SELECT due_date FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	if err := s.db.QueryRow(query, taskID, userID).Scan(&due); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, ErrTaskNotFound
+		}
+		s.logger.Error("Failed to check task ownership", zap.Error(err))
+		return time.Time{}, err
+	}
+	return due.Time, nil
+}
+
+//! \fn GetReminders(taskID string, userID int) ([]models.Reminder, error)
+//! \brief Retrieves the reminders of a task, earliest first.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \return List of reminders and error (if any).
+func (s *Service) GetReminders(taskID string, userID int) ([]models.Reminder, error) {
+	if _, err := s.taskDueDate(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + reminderColumns + ` FROM task_reminders
+              WHERE task_id = $1 ORDER BY remind_at NULLS LAST, id`
+	rows, err := s.db.Query(query, taskID)
+	if err != nil {
+		s.logger.Error("Failed to fetch reminders", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []models.Reminder{}
+	for rows.Next() {
+		var r models.Reminder
+		if err := scanReminder(rows, &r); err != nil {
+			s.logger.Error("Failed to scan reminder", zap.Error(err))
+			return nil, err
+		}
+		reminders = append(reminders, r)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate reminders", zap.Error(err))
+		return nil, err
+	}
+	return reminders, nil
+}
+
+//! \fn AddReminder(taskID string, userID int, r *models.Reminder) error
+//! \brief Adds a reminder to a task.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \param r Reminder data; receives its stored values and, for webhook reminders, the signing secret,
+//!          which is only ever returned here.
+//! \return Error (if any).
+func (s *Service) AddReminder(taskID string, userID int, r *models.Reminder) error {
+	due, err := s.taskDueDate(taskID, userID)
+	if err != nil {
+		return err
+	}
+
+	if r.Channel == "" {
+		r.Channel = ChannelInApp
+	}
+	var secret *string
+	if r.Channel == ChannelWebhook {
+		if !safehttp.PublicURL(r.WebhookURL) {
+			return ErrInvalidWebhook
+		}
+		generated, err := signing.NewSecret()
+		if err != nil {
+			s.logger.Error("Failed to generate reminder secret", zap.Error(err))
+			return err
+		}
+		secret = &generated
+	} else {
+		r.WebhookURL = ""
+	}
+
+	var offset *int64
+	var remindAt *time.Time
+	switch {
+	case r.Before != "" && r.RemindAt == nil:
+		d, err := time.ParseDuration(r.Before)
+		if err != nil || d < 0 {
+			return ErrInvalidReminder
+		}
+		if due.IsZero() {
+			return ErrNoDueDate
+		}
+		seconds := int64(d / time.Second)
+		at := due.Add(-time.Duration(seconds) * time.Second)
+		offset, remindAt = &seconds, &at
+	case r.Before == "" && r.RemindAt != nil:
+		at := r.RemindAt.UTC()
+		remindAt = &at
+	default:
+		return ErrInvalidReminder
+	}
+
+	var webhookURL *string
+	if r.WebhookURL != "" {
+		webhookURL = &r.WebhookURL
+	}
+	query := `INSERT INTO task_reminders (task_id, user_id, offset_seconds, remind_at, channel, webhook_url, webhook_secret)
+              VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING ` + reminderColumns
+	err = scanReminder(s.db.QueryRow(query, taskID, userID, offset, remindAt, r.Channel, webhookURL, secret), r)
+	if err != nil {
+		s.logger.Error("Failed to add reminder", zap.Error(err))
+		return err
+	}
+	r.Secret = ""
+	if secret != nil {
+		r.Secret = *secret
+	}
+
+	s.logger.Info("Reminder added", zap.Int("reminder_id", r.ID), zap.String("task_id", taskID))
+	return nil
+}
+
+//! \fn DeleteReminder(taskID, reminderID string, userID int) error
+//! \brief Removes a reminder from a task.
+//! \param taskID ID of the task.
+//! \param reminderID ID of the reminder.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) DeleteReminder(taskID, reminderID string, userID int) error {
+	if _, err := s.taskDueDate(taskID, userID); err != nil {
+		return err
+	}
+
+	result, err := s.db.Exec(`DELETE FROM task_reminders WHERE id = $1 AND task_id = $2`, reminderID, taskID)
+	if err != nil {
+		s.logger.Error("Failed to delete reminder", zap.Error(err))
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	s.logger.Info("Reminder deleted", zap.String("reminder_id", reminderID), zap.String("task_id", taskID))
+	return nil
+}
+
+//! \fn Reschedule(tx *sql.Tx, taskID int, dueDate *time.Time) error
+//! \brief Recomputes a task's relative reminders after its due date changed, within the caller's transaction.
+//!        Reminders that now lie in the future are re-armed even if they were already sent.
+//! \param tx Open transaction changing the task.
+//! \param taskID ID of the task.
+//! \param dueDate New due date; nil disables the relative reminders.
+//! \return Error (if any).
+func Reschedule(tx *sql.Tx, taskID int, dueDate *time.Time) error {
+	if dueDate == nil {
+		_, err := tx.Exec(`UPDATE task_reminders SET remind_at = NULL
+                           WHERE task_id = $1 AND offset_seconds IS NOT NULL`, taskID)
+		return err
+	}
+	query := `UPDATE task_reminders r SET remind_at = n.remind_at,
+                     sent_at = CASE WHEN n.remind_at > $3 THEN NULL ELSE r.sent_at END,
+                     attempts = CASE WHEN n.remind_at > $3 THEN 0 ELSE r.attempts END,
+                     locked_until = NULL, last_error = NULL
+              FROM (SELECT id, $2::timestamp - offset_seconds * INTERVAL '1 second' AS remind_at
+                    FROM task_reminders WHERE task_id = $1 AND offset_seconds IS NOT NULL) n
+              WHERE r.id = n.id`
+	_, err := tx.Exec(query, taskID, *dueDate, time.Now().UTC())
+	return err
+}
+
+//! \fn CopyRelative(tx *sql.Tx, fromTaskID, toTaskID int, dueDate time.Time) error
+//! \brief Gives a new task the relative reminders of another, e.g. for the next occurrence of a recurring task.
+//! \param tx Open transaction creating the task.
+//! \param fromTaskID ID of the task to copy from.
+//! \param toTaskID ID of the new task.
+//! \param dueDate Due date of the new task.
+//! \return Error (if any).
+func CopyRelative(tx *sql.Tx, fromTaskID, toTaskID int, dueDate time.Time) error {
+	query := `INSERT INTO task_reminders (task_id, user_id, offset_seconds, remind_at, channel, webhook_url, webhook_secret)
+              SELECT $2, user_id, offset_seconds, $3::timestamp - offset_seconds * INTERVAL '1 second', channel,
+                     webhook_url, webhook_secret
+              FROM task_reminders WHERE task_id = $1 AND offset_seconds IS NOT NULL`
+	_, err := tx.Exec(query, fromTaskID, toTaskID, dueDate)
+	return err
+}
+
+//! \fn GetNotifications(userID int, unreadOnly bool) ([]models.Notification, error)
+//! \brief Retrieves a user's latest in-app notifications, newest first.
+//! \param userID ID of the user.
+//! \param unreadOnly Whether to skip notifications already marked as read.
+//! \return List of notifications and error (if any).
+func (s *Service) GetNotifications(userID int, unreadOnly bool) ([]models.Notification, error) {
+	query := `SELECT id, user_id, task_id, message, created_at, read_at FROM notifications
+              WHERE user_id = $1 AND ($2 = FALSE OR read_at IS NULL) ORDER BY id DESC LIMIT 100`
+	rows, err := s.db.Query(query, userID, unreadOnly)
+	if err != nil {
+		s.logger.Error("Failed to fetch notifications", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.TaskID, &n.Message, &n.CreatedAt, &n.ReadAt); err != nil {
+			s.logger.Error("Failed to scan notification", zap.Error(err))
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate notifications", zap.Error(err))
+		return nil, err
+	}
+	return notifications, nil
+}
+
+//! \fn MarkRead(notificationID string, userID int) error
+//! \brief Marks one of the user's notifications as read.
+//! \param notificationID ID of the notification.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) MarkRead(notificationID string, userID int) error {
+	query := `UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP) WHERE id = $1 AND user_id = $2`
+	result, err := s.db.Exec(query, notificationID, userID)
+	if err != nil {
+		s.logger.Error("Failed to mark notification as read", zap.Error(err))
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,370 @@
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // TZID must resolve even where the system has no zone database
+)
+
+//! \brief Supported recurrence frequencies.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+//! \const maxPeriods
+//! \brief Upper bound on the periods scanned while expanding a rule, so a rule
+//!        that can never match again cannot loop forever.
+const maxPeriods = 10000
+
+//! \var ErrInvalidRule
+//! \brief Returned when a recurrence rule cannot be parsed or uses an unsupported part.
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+//! \var weekdays
+//! \brief iCalendar two-letter weekday codes.
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+//! \struct Day
+//! \brief A BYDAY entry: a weekday, optionally restricted to its Nth (or Nth-from-last) occurrence in the month.
+type Day struct {
+	N       int
+	Weekday time.Weekday
+}
+
+//! \struct Rule
+//! \brief A parsed subset of an iCalendar (RFC 5545) RRULE: FREQ, INTERVAL, BYDAY, COUNT and UNTIL.
+//!        COUNT includes the occurrence the rule is anchored at, as DTSTART does in iCalendar.
+//!        Location comes from a TZID part standing in for DTSTART's TZID parameter; weekdays,
+//!        month boundaries and the time of day are evaluated there, so occurrences follow DST.
+type Rule struct {
+	Freq     string
+	Interval int
+	ByDay    []Day
+	Count    int
+	Until    *time.Time
+	Location *time.Location
+}
+
+//! \fn Parse(value string) (*Rule, error)
+//! \brief Parses an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10".
+//! \param value Rule text, with or without the "RRULE:" prefix.
+//! \return Parsed rule and error (if any).
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, ErrInvalidRule
+	}
+
+	r := &Rule{Interval: 1}
+	seen := map[string]bool{}
+	var until string
+	for _, part := range strings.Split(value, ";") {
+		name, raw, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		raw = strings.TrimSpace(raw)
+		arg := strings.ToUpper(raw)
+		if !ok || arg == "" || seen[name] {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			if arg != Daily && arg != Weekly && arg != Monthly {
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRule, arg)
+			}
+			r.Freq = arg
+		case "INTERVAL":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRule)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRule)
+			}
+			r.Count = n
+		case "UNTIL":
+			// Parsed once the rule's location is known.
+			until = arg
+		case "TZID":
+			loc, err := time.LoadLocation(raw)
+			if err != nil || raw == "Local" {
+				return nil, fmt.Errorf("%w: unknown TZID %q", ErrInvalidRule, raw)
+			}
+			r.Location = loc
+		case "BYDAY":
+			for _, item := range strings.Split(arg, ",") {
+				day, err := parseDay(item)
+				if err != nil {
+					return nil, err
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %q", ErrInvalidRule, name)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if until != "" {
+		t, err := parseUntil(until, r.Location)
+		if err != nil {
+			return nil, err
+		}
+		r.Until = &t
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRule)
+	}
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != Monthly {
+			return nil, fmt.Errorf("%w: numbered BYDAY requires FREQ=MONTHLY", ErrInvalidRule)
+		}
+	}
+	return r, nil
+}
+
+//! \fn parseDay(item string) (Day, error)
+//! \brief Parses a BYDAY entry such as "MO", "2TU" or "-1FR".
+//! \param item BYDAY entry.
+//! \return Parsed day and error (if any).
+func parseDay(item string) (Day, error) {
+	item = strings.TrimSpace(item)
+	if len(item) < 2 {
+		return Day{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRule, item)
+	}
+	weekday, ok := weekdays[item[len(item)-2:]]
+	if !ok {
+		return Day{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRule, item)
+	}
+	day := Day{Weekday: weekday}
+	if prefix := item[:len(item)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return Day{}, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRule, item)
+		}
+		day.N = n
+	}
+	return day, nil
+}
+
+//! \fn parseUntil(value string, loc *time.Location) (time.Time, error)
+//! \brief Parses an UNTIL date or date-time; values without a zone are taken in the rule's location.
+//! \param value UNTIL value.
+//! \param loc Location of the rule; nil for UTC.
+//! \return Parsed time and error (if any).
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			if layout == "20060102" {
+				// A date-only UNTIL includes the whole day.
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid UNTIL %q", ErrInvalidRule, value)
+}
+
+//! \fn String() string
+//! \brief Formats the rule in canonical RRULE form.
+//! \return Rule text.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if r.Location != nil {
+		parts = append(parts, "TZID="+r.Location.String())
+	}
+	return strings.Join(parts, ";")
+}
+
+//! \fn String() string
+//! \brief Formats a BYDAY entry.
+//! \return Entry text such as "-1FR".
+func (d Day) String() string {
+	for code, weekday := range weekdays {
+		if weekday == d.Weekday {
+			if d.N != 0 {
+				return strconv.Itoa(d.N) + code
+			}
+			return code
+		}
+	}
+	return ""
+}
+
+//! \fn Next(start time.Time, n int) []time.Time
+//! \brief Expands the rule anchored at start and returns up to n occurrences after it.
+//! \param start First occurrence of the series (DTSTART); its time of day is kept in the rule's
+//!        location, or in start's own location if the rule has none.
+//! \param n Maximum number of occurrences to return.
+//! \return Occurrences in ascending order and in start's location; fewer than n when the series ends.
+func (r *Rule) Next(start time.Time, n int) []time.Time {
+	if r.Count > 0 && n > r.Count-1 {
+		n = r.Count - 1
+	}
+	loc := start.Location()
+	if r.Location != nil {
+		start = start.In(r.Location)
+	}
+	var result []time.Time
+	for period := 0; period < maxPeriods && len(result) < n; period++ {
+		for _, t := range r.candidates(start, period*r.Interval) {
+			if !t.After(start) {
+				continue
+			}
+			if r.Until != nil && t.After(*r.Until) {
+				return result
+			}
+			result = append(result, t.In(loc))
+			if len(result) == n {
+				break
+			}
+		}
+	}
+	return result
+}
+
+//! \fn candidates(start time.Time, offset int) []time.Time
+//! \brief Lists the dates matching the rule in the period offset periods after the one containing start.
+//! \param start Anchor of the series.
+//! \param offset Number of periods (days, weeks or months) after the anchor period.
+//! \return Matching times in ascending order.
+func (r *Rule) candidates(start time.Time, offset int) []time.Time {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	}
+
+	var result []time.Time
+	switch r.Freq {
+	case Daily:
+		t := at(start.Year(), start.Month(), start.Day()+offset)
+		if len(r.ByDay) == 0 || r.matchesWeekday(t.Weekday()) {
+			result = append(result, t)
+		}
+	case Weekly:
+		// Weeks start on Monday (the iCalendar default WKST).
+		monday := at(start.Year(), start.Month(), start.Day()-(int(start.Weekday())+6)%7+7*offset)
+		if len(r.ByDay) == 0 {
+			result = append(result, monday.AddDate(0, 0, (int(start.Weekday())+6)%7))
+		}
+		for _, day := range r.ByDay {
+			result = append(result, monday.AddDate(0, 0, (int(day.Weekday)+6)%7))
+		}
+	case Monthly:
+		first := at(start.Year(), start.Month()+time.Month(offset), 1)
+		days := daysIn(first)
+		if len(r.ByDay) == 0 {
+			// Months without the anchor's day are skipped, as in iCalendar.
+			if start.Day() <= days {
+				result = append(result, first.AddDate(0, 0, start.Day()-1))
+			}
+		}
+		for _, day := range r.ByDay {
+			var matches []time.Time
+			for d := 0; d < days; d++ {
+				if t := first.AddDate(0, 0, d); t.Weekday() == day.Weekday {
+					matches = append(matches, t)
+				}
+			}
+			switch {
+			case day.N == 0:
+				result = append(result, matches...)
+			case day.N > 0 && day.N <= len(matches):
+				result = append(result, matches[day.N-1])
+			case day.N < 0 && -day.N <= len(matches):
+				result = append(result, matches[len(matches)+day.N])
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return dedupe(result)
+}
+
+//! \fn matchesWeekday(weekday time.Weekday) bool
+//! \brief Reports whether a weekday is listed in BYDAY.
+//! \param weekday Weekday to check.
+//! \return True if listed.
+func (r *Rule) matchesWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+//! \fn daysIn(t time.Time) int
+//! \brief Counts the days of the month containing t.
+//! \param t Any time in the month.
+//! \return Number of days.
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+//! \fn dedupe(times []time.Time) []time.Time
+//! \brief Removes adjacent duplicates from a sorted list.
+//! \param times Sorted times.
+//! \return Distinct times.
+func dedupe(times []time.Time) []time.Time {
+	var result []time.Time
+	for i, t := range times {
+		if i == 0 || !t.Equal(times[i-1]) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+//! \fn Advance() *Rule
+//! \brief Returns the rule that continues the series from its next occurrence:
+//!        COUNT is reduced by the occurrence that was consumed.
+//! \return Continuing rule, or nil when the series is over.
+func (r *Rule) Advance() *Rule {
+	next := *r
+	if next.Count > 0 {
+		if next.Count == 1 {
+			return nil
+		}
+		next.Count--
+	}
+	return &next
+}
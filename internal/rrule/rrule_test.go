@@ -0,0 +1,171 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"prefix and case", "RRULE:freq=daily", "FREQ=DAILY"},
+		{"interval", "FREQ=WEEKLY;INTERVAL=2", "FREQ=WEEKLY;INTERVAL=2"},
+		{"interval of one", "FREQ=WEEKLY;INTERVAL=1", "FREQ=WEEKLY"},
+		{"weekdays", "FREQ=WEEKLY;BYDAY=MO, we ,FR", "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{"numbered weekdays", "FREQ=MONTHLY;BYDAY=2TU,-1FR", "FREQ=MONTHLY;BYDAY=2TU,-1FR"},
+		{"count", "FREQ=DAILY;COUNT=5", "FREQ=DAILY;COUNT=5"},
+		{"until date", "FREQ=DAILY;UNTIL=20260131", "FREQ=DAILY;UNTIL=20260131T235959Z"},
+		{"until date-time", "FREQ=DAILY;UNTIL=20260131T120000Z", "FREQ=DAILY;UNTIL=20260131T120000Z"},
+		{"time zone keeps its case", "tzid=America/New_York;FREQ=WEEKLY;BYDAY=MO",
+			"FREQ=WEEKLY;BYDAY=MO;TZID=America/New_York"},
+		{"until date in the time zone", "FREQ=DAILY;UNTIL=20260131;TZID=America/New_York",
+			"FREQ=DAILY;UNTIL=20260201T045959Z;TZID=America/New_York"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.value)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.value, err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("Parse(%q).String() = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"empty", ""},
+		{"prefix only", "RRULE:"},
+		{"missing FREQ", "INTERVAL=2"},
+		{"unsupported FREQ", "FREQ=YEARLY"},
+		{"unsupported part", "FREQ=DAILY;BYMONTH=1"},
+		{"repeated part", "FREQ=DAILY;FREQ=WEEKLY"},
+		{"malformed part", "FREQ=DAILY;COUNT"},
+		{"zero interval", "FREQ=DAILY;INTERVAL=0"},
+		{"zero count", "FREQ=DAILY;COUNT=0"},
+		{"count and until", "FREQ=DAILY;COUNT=2;UNTIL=20260101"},
+		{"invalid until", "FREQ=DAILY;UNTIL=tomorrow"},
+		{"invalid weekday", "FREQ=WEEKLY;BYDAY=XX"},
+		{"numbered weekday out of range", "FREQ=MONTHLY;BYDAY=6MO"},
+		{"numbered weekday zero", "FREQ=MONTHLY;BYDAY=0MO"},
+		{"numbered weekday not monthly", "FREQ=WEEKLY;BYDAY=1MO"},
+		{"unknown time zone", "FREQ=DAILY;TZID=Mars/Olympus_Mons"},
+		{"local time zone", "FREQ=DAILY;TZID=Local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.value); !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidRule", tt.value, err)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+	utc := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		n     int
+		want  []time.Time
+	}{
+		{"daily", "FREQ=DAILY", date(2026, 1, 30), 3,
+			[]time.Time{date(2026, 1, 31), date(2026, 2, 1), date(2026, 2, 2)}},
+		{"daily on weekends", "FREQ=DAILY;BYDAY=SA,SU", date(2026, 1, 2), 3,
+			[]time.Time{date(2026, 1, 3), date(2026, 1, 4), date(2026, 1, 10)}},
+		{"weekly on weekdays", "FREQ=WEEKLY;BYDAY=MO,WE,FR", date(2026, 1, 5), 4,
+			[]time.Time{date(2026, 1, 7), date(2026, 1, 9), date(2026, 1, 12), date(2026, 1, 14)}},
+		{"every other week", "FREQ=WEEKLY;INTERVAL=2", date(2026, 1, 7), 2,
+			[]time.Time{date(2026, 1, 21), date(2026, 2, 4)}},
+		{"month end skips short months", "FREQ=MONTHLY", date(2026, 1, 31), 3,
+			[]time.Time{date(2026, 3, 31), date(2026, 5, 31), date(2026, 7, 31)}},
+		{"leap day", "FREQ=MONTHLY;INTERVAL=12", date(2024, 2, 29), 1,
+			[]time.Time{date(2028, 2, 29)}},
+		{"second Tuesday", "FREQ=MONTHLY;BYDAY=2TU", date(2026, 1, 13), 2,
+			[]time.Time{date(2026, 2, 10), date(2026, 3, 10)}},
+		{"last Friday", "FREQ=MONTHLY;BYDAY=-1FR", date(2026, 1, 30), 3,
+			[]time.Time{date(2026, 2, 27), date(2026, 3, 27), date(2026, 4, 24)}},
+		{"fifth Friday skips months without one", "FREQ=MONTHLY;BYDAY=5FR", date(2026, 1, 30), 2,
+			[]time.Time{date(2026, 5, 29), date(2026, 7, 31)}},
+		{"count includes the start", "FREQ=DAILY;INTERVAL=2;COUNT=3", date(2026, 1, 1), 10,
+			[]time.Time{date(2026, 1, 3), date(2026, 1, 5)}},
+		{"count of one", "FREQ=DAILY;COUNT=1", date(2026, 1, 1), 10, nil},
+		{"until date is inclusive", "FREQ=DAILY;UNTIL=20260104", date(2026, 1, 1), 10,
+			[]time.Time{date(2026, 1, 2), date(2026, 1, 3), date(2026, 1, 4)}},
+		{"until before the next occurrence", "FREQ=WEEKLY;UNTIL=20260105T000000Z", date(2026, 1, 1), 10, nil},
+		// Monday 20:00 in New York is Tuesday 01:00 UTC in winter and 00:00 UTC once DST starts on March 8.
+		{"weekday and time of day in the time zone", "FREQ=WEEKLY;BYDAY=MO;TZID=America/New_York",
+			utc(2026, 3, 3, 1), 3, []time.Time{utc(2026, 3, 10, 0), utc(2026, 3, 17, 0), utc(2026, 3, 24, 0)}},
+		{"month end in the time zone", "FREQ=MONTHLY;BYDAY=-1FR;TZID=Asia/Tokyo",
+			utc(2026, 1, 29, 15), 2, []time.Time{utc(2026, 2, 26, 15), utc(2026, 3, 26, 15)}},
+		{"without a time zone weekdays are UTC", "FREQ=WEEKLY;BYDAY=MO",
+			utc(2026, 3, 3, 1), 1, []time.Time{utc(2026, 3, 9, 1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.rule, err)
+			}
+			got := rule.Next(tt.start, tt.n)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Next() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("Next()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		want string
+	}{
+		{"without count", "FREQ=WEEKLY;BYDAY=MO", "FREQ=WEEKLY;BYDAY=MO"},
+		{"with until", "FREQ=DAILY;UNTIL=20260131", "FREQ=DAILY;UNTIL=20260131T235959Z"},
+		{"count decreases", "FREQ=DAILY;COUNT=3", "FREQ=DAILY;COUNT=2"},
+		{"last occurrence", "FREQ=DAILY;COUNT=1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.rule, err)
+			}
+			before := rule.String()
+			next := rule.Advance()
+			if got := rule.String(); got != before {
+				t.Errorf("Advance() changed the original rule to %q", got)
+			}
+			if tt.want == "" {
+				if next != nil {
+					t.Errorf("Advance() = %q, want nil", next.String())
+				}
+				return
+			}
+			if next == nil || next.String() != tt.want {
+				t.Errorf("Advance() = %v, want %q", next, tt.want)
+			}
+		})
+	}
+}
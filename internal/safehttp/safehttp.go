@@ -0,0 +1,118 @@
+package safehttp
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+//! \var ErrForbiddenAddress
+//! \brief Returned when a request would connect to a loopback, private or otherwise internal address.
+var ErrForbiddenAddress = errors.New("destination address is not allowed")
+
+//! \var blockedNetworks
+//! \brief Ranges not covered by the net.IP predicates that must not be reached either.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, including broadcast
+	"64:ff9b::/96",  // NAT64, which can embed any IPv4 address
+)
+
+//! \fn mustParseCIDRs(cidrs ...string) []*net.IPNet
+//! \brief Parses a fixed list of networks.
+//! \param cidrs Networks in CIDR notation.
+//! \return Parsed networks.
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+//! \fn Allowed(ip net.IP) bool
+//! \brief Reports whether an address is a public unicast address outgoing requests may connect to.
+//! \param ip Address to check.
+//! \return False for loopback, private, link-local, unspecified, multicast and reserved addresses.
+func Allowed(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+//! \fn PublicURL(raw string) bool
+//! \brief Reports whether a URL is an absolute http(s) URL that does not name an internal host.
+//!        Host names are not resolved here; the client of NewClient checks every connection.
+//! \param raw URL to check.
+//! \return True if the URL may be requested.
+func PublicURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return Allowed(ip)
+	}
+	return true
+}
+
+//! \fn control(network, address string, c syscall.RawConn) error
+//! \brief Dialer hook rejecting connections to addresses that are not Allowed. It runs after name
+//!        resolution for every connection, so DNS rebinding and redirects are covered as well.
+//! \param network Network of the connection.
+//! \param address Resolved "ip:port" about to be connected to.
+//! \param c Raw connection (unused).
+//! \return ErrForbiddenAddress (if not allowed).
+func control(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !Allowed(net.ParseIP(host)) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+//! \fn NewClient(timeout time.Duration) *http.Client
+//! \brief Initializes an HTTP client for user-supplied URLs that refuses to reach internal addresses.
+//!        Proxies from the environment are ignored, since the check would only see the proxy.
+//! \param timeout Bound on each request, including redirects.
+//! \return Pointer to initialized client.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+//! \fn NewSecret() (string, error)
+//! \brief Generates a random signing secret.
+//! \return Hex-encoded secret and error (if any).
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+//! \fn Sign(secret string, timestamp int64, body []byte) string
+//! \brief Computes the signature receivers compare against the X-Webhook-Signature header:
+//!        HMAC-SHA256 over "<timestamp>.<body>", keyed with the secret.
+//! \param secret Signing secret.
+//! \param timestamp Unix time sent in X-Webhook-Timestamp.
+//! \param body Request body.
+//! \return Signature in the form "sha256=<hex>".
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,28 @@
+package signing
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{"payload", "secret", 1700000000, `{"a":1}`,
+			"sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"},
+		{"other secret", "other", 1700000000, `{"a":1}`,
+			"sha256=2cb38bd50b3aa61b12df512da616c9577f2a99edb9467110d361a655e1ad3bd5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if Sign("secret", 1700000001, []byte(`{"a":1}`)) == tests[0].want {
+		t.Error("Sign() does not cover the timestamp")
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//! \struct Local
+//! \brief Stores blobs as files in a directory on the local filesystem.
+type Local struct {
+	dir string
+}
+
+//! \fn NewLocal(dir string) (*Local, error)
+//! \brief Initializes local storage, creating the directory if needed.
+//! \param dir Directory holding the blobs.
+//! \return Pointer to initialized Local and error (if any).
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &Local{dir: dir}, nil
+}
+
+//! \fn path(key string) (string, error)
+//! \brief Maps a key to a file path inside the storage directory.
+//! \param key Blob key.
+//! \return File path and error (if the key is not a plain file name).
+func (l *Local) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return filepath.Join(l.dir, key), nil
+}
+
+//! \fn Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+//! \brief Writes a blob atomically via a temporary file.
+//! \param ctx Request context.
+//! \param key Blob key.
+//! \param r Blob content.
+//! \param size Content length.
+//! \param contentType Content type (unused by this backend).
+//! \return Error (if any).
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(l.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+//! \fn Get(ctx context.Context, key string) (io.ReadCloser, error)
+//! \brief Opens a blob file.
+//! \param ctx Request context.
+//! \param key Blob key.
+//! \return Blob reader and error (if any).
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+//! \fn Delete(ctx context.Context, key string) error
+//! \brief Removes a blob file.
+//! \param ctx Request context.
+//! \param key Blob key.
+//! \return Error (if any).
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
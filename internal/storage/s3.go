@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//! \const emptyPayloadHash
+//! \brief SHA-256 of an empty body, used to sign requests without a payload.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+//! \struct S3
+//! \brief Stores blobs in an S3-compatible object store using path-style requests and SigV4 signing.
+//!        Works against AWS S3 and local stand-ins such as MinIO.
+type S3 struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+//! \fn NewS3(endpoint, bucket, region, accessKey, secretKey string) (*S3, error)
+//! \brief Initializes S3-compatible storage.
+//! \param endpoint Base URL of the object store, e.g. http://localhost:9000.
+//! \param bucket Bucket holding the blobs.
+//! \param region Signing region.
+//! \param accessKey Access key ID.
+//! \param secretKey Secret access key.
+//! \return Pointer to initialized S3 and error (if any).
+func NewS3(endpoint, bucket, region, accessKey, secretKey string) (*S3, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3{
+		endpoint:  u,
+		bucket:    bucket,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+//! \fn Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+//! \brief Uploads a blob with a streaming, unsigned payload.
+//! \param ctx Request context.
+//! \param key Blob key.
+//! \param r Blob content.
+//! \param size Content length.
+//! \param contentType Content type stored with the object.
+//! \return Error (if any).
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r, "UNSIGNED-PAYLOAD")
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError(resp)
+	}
+	return nil
+}
+
+//! \fn Get(ctx context.Context, key string) (io.ReadCloser, error)
+//! \brief Downloads a blob.
+//! \param ctx Request context.
+//! \param key Blob key.
+//! \return Blob reader and error (if any).
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, s.responseError(resp)
+	}
+	return resp.Body, nil
+}
+
+//! \fn Delete(ctx context.Context, key string) error
+//! \brief Removes a blob.
+//! \param ctx Request context.
+//! \param key Blob key.
+//! \return Error (if any).
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK &&
+		resp.StatusCode != http.StatusNotFound {
+		return s.responseError(resp)
+	}
+	return nil
+}
+
+//! \fn newRequest(ctx context.Context, method, key string, body io.Reader, payloadHash string) (*http.Request, error)
+//! \brief Builds a signed object request.
+//! \param ctx Request context.
+//! \param method HTTP method.
+//! \param key Blob key.
+//! \param body Request body (may be nil).
+//! \param payloadHash Hex SHA-256 of the body or UNSIGNED-PAYLOAD.
+//! \return Signed request and error (if any).
+func (s *S3) newRequest(ctx context.Context, method, key string, body io.Reader, payloadHash string) (*http.Request, error) {
+	u := *s.endpoint
+	base := strings.TrimSuffix(u.Path, "/")
+	u.Path = base + "/" + s.bucket + "/" + key
+	u.RawPath = base + "/" + uriEncode(s.bucket) + "/" + uriEncode(key)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, payloadHash, time.Now().UTC())
+	return req, nil
+}
+
+//! \fn sign(req *http.Request, payloadHash string, now time.Time)
+//! \brief Adds AWS Signature Version 4 headers to a request.
+//! \param req Request to sign.
+//! \param payloadHash Hex SHA-256 of the body or UNSIGNED-PAYLOAD.
+//! \param now Signing time in UTC.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+//! \fn responseError(resp *http.Response) error
+//! \brief Converts an unexpected object store response into an error.
+//! \param resp Response to describe.
+//! \return Error including the status and the start of the body.
+func (s *S3) responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s %s: %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, body)
+}
+
+//! \fn uriEncode(segment string) string
+//! \brief Percent-encodes a path segment the way SigV4 canonicalizes it: everything but the unreserved
+//!        characters, including "/", so that keys containing slashes stay single objects.
+//! \param segment Bucket name or blob key.
+//! \return Encoded segment.
+func uriEncode(segment string) string {
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+//! \fn hmacSHA256(key []byte, data string) []byte
+//! \brief Computes an HMAC-SHA256.
+//! \param key HMAC key.
+//! \param data Message.
+//! \return MAC bytes.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
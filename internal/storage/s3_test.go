@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestS3Sign(t *testing.T) {
+	s, err := NewS3("https://s3.example.com", "attachments", "eu-central-1", "AKID", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://s3.example.com/attachments/a%20b.txt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.sign(req, emptyPayloadHash, time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKID/20260310/eu-central-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, " +
+		"Signature=81f8f1fd8cd041110d4feffb0fcd054329c971225d4998f289363c696cf46d6b"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20260310T093000Z" {
+		t.Errorf("X-Amz-Date = %q", got)
+	}
+}
+
+// fakeS3 is an in-memory stand-in for the object store that checks the signing headers of every request.
+type fakeS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string]string
+	types   map[string]string
+	status  int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wantHash := emptyPayloadHash
+	if r.Method == http.MethodPut {
+		wantHash = "UNSIGNED-PAYLOAD"
+	}
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != wantHash {
+		f.t.Errorf("%s X-Amz-Content-Sha256 = %q, want %q", r.Method, got, wantHash)
+	}
+	date := r.Header.Get("X-Amz-Date")
+	if len(date) != len("20060102T150405Z") {
+		f.t.Errorf("%s X-Amz-Date = %q", r.Method, date)
+	}
+	prefix := "AWS4-HMAC-SHA256 Credential=AKID/" + date[:8] + "/us-east-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, prefix) || len(auth) != len(prefix)+64 {
+		f.t.Errorf("%s Authorization = %q", r.Method, auth)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		io.WriteString(w, "<Error><Code>AccessDenied</Code></Error>")
+		return
+	}
+	key := r.URL.EscapedPath()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = string(body)
+		f.types[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, body)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3(t *testing.T) {
+	fake := &fakeS3{t: t, objects: map[string]string{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	s, err := NewS3(server.URL, "attachments", "", "AKID", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key := "7/report (v2).pdf"
+
+	if err := s.Put(ctx, key, strings.NewReader("content"), int64(len("content")), "application/pdf"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if got := fake.types["/attachments/7%2Freport%20%28v2%29.pdf"]; got != "application/pdf" {
+		t.Errorf("stored content type = %q; objects %v", got, fake.objects)
+	}
+
+	body, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "content" {
+		t.Errorf("Get() = %q, want %q", data, "content")
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete() of a missing blob error = %v", err)
+	}
+}
+
+func TestS3ErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(s *S3) error
+	}{
+		{"put", func(s *S3) error {
+			return s.Put(context.Background(), "k", strings.NewReader("x"), 1, "text/plain")
+		}},
+		{"get", func(s *S3) error {
+			_, err := s.Get(context.Background(), "k")
+			return err
+		}},
+		{"delete", func(s *S3) error {
+			return s.Delete(context.Background(), "k")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeS3{t: t, objects: map[string]string{}, types: map[string]string{}, status: http.StatusForbidden}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+			s, err := NewS3(server.URL, "attachments", "", "AKID", "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tt.call(s)
+			if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "403") ||
+				!strings.Contains(err.Error(), "AccessDenied") {
+				t.Errorf("error = %v, want the 403 response", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+//! \var ErrNotFound
+//! \brief Returned when a blob does not exist.
+var ErrNotFound = errors.New("blob not found")
+
+//! \interface Storage
+//! \brief Stores opaque blobs under generated keys.
+type Storage interface {
+	//! \brief Writes size bytes from r under key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	//! \brief Opens the blob stored under key; the caller closes it.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	//! \brief Removes the blob stored under key; missing blobs are not an error.
+	Delete(ctx context.Context, key string) error
+}
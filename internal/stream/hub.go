@@ -0,0 +1,279 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"task-tracker/internal/events"
+	"task-tracker/internal/models"
+	"task-tracker/internal/tasks"
+	"go.uber.org/zap"
+)
+
+const (
+	replayLimit      = 1000
+	subscriberBuffer = 64
+)
+
+//! \struct Event
+//! \brief A task change addressed to one user, identified by its task history ID.
+type Event struct {
+	ID     int64
+	UserID int
+	Type   string
+	Data   []byte
+}
+
+//! \struct eventData
+//! \brief JSON body of a streamed event.
+type eventData struct {
+	ID        int64                         `json:"id"`
+	Type      string                        `json:"type"`
+	Action    string                        `json:"action"`
+	TaskID    int                           `json:"task_id"`
+	ActorID   *int                          `json:"actor_id"`
+	Changes   map[string]models.FieldChange `json:"changes"`
+	CreatedAt time.Time                     `json:"created_at"`
+}
+
+//! \struct Subscriber
+//! \brief Receives the live events of one user. Its channel is closed if the
+//!        subscriber falls too far behind; the client then resumes via Last-Event-ID.
+type Subscriber struct {
+	userID int
+	events chan Event
+}
+
+//! \fn Events() <-chan Event
+//! \brief Exposes the subscriber's event channel.
+//! \return Channel of live events.
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+//! \struct Hub
+//! \brief Fans task events from the event bus out to the subscribers on this instance.
+//!        Because the bus reaches every instance, events reach clients regardless of which
+//!        instance handled the change.
+type Hub struct {
+	db     *sql.DB
+	bus    events.Bus
+	logger *zap.Logger
+
+	mu   sync.Mutex
+	subs map[int]map[*Subscriber]struct{}
+}
+
+//! \fn NewHub(db *sql.DB, bus events.Bus, logger *zap.Logger) *Hub
+//! \brief Initializes a new event hub.
+//! \param db Database connection.
+//! \param bus Event bus carrying task events.
+//! \param logger Logger instance.
+//! \return Pointer to initialized Hub.
+func NewHub(db *sql.DB, bus events.Bus, logger *zap.Logger) *Hub {
+	return &Hub{
+		db:     db,
+		bus:    bus,
+		logger: logger,
+		subs:   map[int]map[*Subscriber]struct{}{},
+	}
+}
+
+//! \fn Subscribe(userID int) (*Subscriber, func())
+//! \brief Registers a subscriber for a user's live events.
+//! \param userID ID of the user.
+//! \return Subscriber and the function that unregisters it.
+func (h *Hub) Subscribe(userID int) (*Subscriber, func()) {
+	sub := &Subscriber{userID: userID, events: make(chan Event, subscriberBuffer)}
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = map[*Subscriber]struct{}{}
+	}
+	h.subs[userID][sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub, func() { h.remove(sub) }
+}
+
+//! \fn remove(sub *Subscriber)
+//! \brief Unregisters a subscriber and closes its channel, once.
+//! \param sub Subscriber to remove.
+func (h *Hub) remove(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub.userID][sub]; !ok {
+		return
+	}
+	delete(h.subs[sub.userID], sub)
+	if len(h.subs[sub.userID]) == 0 {
+		delete(h.subs, sub.userID)
+	}
+	close(sub.events)
+}
+
+//! \fn publish(ev Event)
+//! \brief Hands an event to the user's subscribers, dropping those that cannot keep up.
+//! \param ev Event to deliver.
+func (h *Hub) publish(ev Event) {
+	h.mu.Lock()
+	var slow []*Subscriber
+	for sub := range h.subs[ev.UserID] {
+		select {
+		case sub.events <- ev:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, sub := range slow {
+		h.logger.Warn("Dropping slow event subscriber", zap.Int("user_id", sub.userID))
+		h.remove(sub)
+	}
+}
+
+//! \fn scanEvent(rows *sql.Rows) (Event, error)
+//! \brief Converts a task history row into a stream event.
+//! \param rows Rows positioned on an entry.
+//! \return Event and error (if any).
+func scanEvent(rows *sql.Rows) (Event, error) {
+	var ev Event
+	var data eventData
+	var changes []byte
+	if err := rows.Scan(&data.ID, &ev.UserID, &data.TaskID, &data.ActorID, &data.Action, &changes,
+		&data.CreatedAt); err != nil {
+		return ev, err
+	}
+	if err := json.Unmarshal(changes, &data.Changes); err != nil {
+		return ev, err
+	}
+	data.Type = tasks.EventType(data.Action)
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return ev, err
+	}
+	ev.ID, ev.Type, ev.Data = data.ID, data.Type, body
+	return ev, nil
+}
+
+//! \const eventQuery
+//! \brief Selects task history entries with the owning user, which is kept for purged tasks too.
+const eventQuery = `SELECT e.id, e.user_id, e.task_id, e.actor_id, e.action, e.changes, e.created_at
+                    FROM task_events e`
+
+//! \fn Replay(userID int, afterID int64) ([]Event, bool, error)
+//! \brief Loads a user's events after the one a client last saw.
+//! \param userID ID of the user.
+//! \param afterID Last-Event-ID sent by the client.
+//! \return Missed events, whether too many were missed to replay, and error (if any).
+func (h *Hub) Replay(userID int, afterID int64) ([]Event, bool, error) {
+	query := eventQuery + ` WHERE e.user_id = $1 AND e.id > $2 ORDER BY e.id LIMIT $3`
+	rows, err := h.db.Query(query, userID, afterID, replayLimit+1)
+	if err != nil {
+		h.logger.Error("Failed to replay events", zap.Error(err))
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			h.logger.Error("Failed to scan event", zap.Error(err))
+			return nil, false, err
+		}
+		events = append(events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	if len(events) > replayLimit {
+		return nil, true, nil
+	}
+	return events, false, nil
+}
+
+//! \fn Run(ctx context.Context)
+//! \brief Forwards task events from the bus to subscribers until the context is cancelled.
+//!        Whenever events may have been lost (bus reconnect or overflow) all subscribers are
+//!        dropped so their clients reconnect and catch up via Last-Event-ID.
+//! \param ctx Context controlling the hub's lifetime.
+func (h *Hub) Run(ctx context.Context) {
+	sub := h.bus.Subscribe()
+	defer func() { sub.Close() }()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				h.logger.Warn("Event hub fell behind the bus; resubscribing")
+				sub = h.bus.Subscribe()
+				h.dropAll()
+				continue
+			}
+			if ev.Type == events.Reconnected {
+				h.dropAll()
+				continue
+			}
+			if strings.HasPrefix(ev.Type, "task.") && ev.ID > 0 {
+				h.forward(ctx, ev)
+			}
+		}
+	}
+}
+
+//! \fn forward(ctx context.Context, ev events.Event)
+//! \brief Loads the history entry behind a bus event and hands it to the user's subscribers.
+//! \param ctx Context controlling the query.
+//! \param ev Task event from the bus.
+func (h *Hub) forward(ctx context.Context, ev events.Event) {
+	h.mu.Lock()
+	listening := len(h.subs[ev.UserID]) > 0
+	h.mu.Unlock()
+	if !listening {
+		return
+	}
+
+	rows, err := h.db.QueryContext(ctx, eventQuery+` WHERE e.id = $1`, ev.ID)
+	if err != nil {
+		if ctx.Err() == nil {
+			h.logger.Error("Failed to load event", zap.Int64("event_id", ev.ID), zap.Error(err))
+		}
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		out, err := scanEvent(rows)
+		if err != nil {
+			h.logger.Error("Failed to scan event", zap.Error(err))
+			continue
+		}
+		h.publish(out)
+	}
+	if err := rows.Err(); err != nil {
+		h.logger.Error("Failed to iterate events", zap.Error(err))
+	}
+}
+
+//! \fn dropAll()
+//! \brief Disconnects every subscriber on this instance.
+func (h *Hub) dropAll() {
+	h.mu.Lock()
+	var all []*Subscriber
+	for _, subs := range h.subs {
+		for sub := range subs {
+			all = append(all, sub)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, sub := range all {
+		h.remove(sub)
+	}
+}
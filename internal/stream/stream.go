@@ -0,0 +1,103 @@
+package stream
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	retryMillis       = 3000
+)
+
+//! \fn EventsHandler(h *Hub) gin.HandlerFunc
+//! \brief Creates a Gin handler streaming the user's task events as Server-Sent Events.
+//!        Clients resume after a disconnect with the Last-Event-ID header (or last_event_id query parameter).
+//! \param h Event hub instance.
+//! \return Gin handler function.
+func EventsHandler(h *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var lastID int64
+		raw := c.GetHeader("Last-Event-ID")
+		if raw == "" {
+			raw = c.Query("last_event_id")
+		}
+		if raw != "" {
+			v, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || v < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID"})
+				return
+			}
+			lastID = v
+		}
+
+		userID, _ := c.Get("user_id")
+		// Subscribe before replaying so nothing committed in between is lost.
+		sub, unsubscribe := h.Subscribe(userID.(int))
+		defer unsubscribe()
+
+		replayed := map[int64]bool{}
+		var missed []Event
+		reset := false
+		if lastID > 0 {
+			var err error
+			if missed, reset, err = h.Replay(userID.(int), lastID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+				return
+			}
+		}
+
+		c.Header("Content-Type", sse.ContentType)
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		c.Render(-1, sse.Event{Event: "ready", Retry: retryMillis, Data: "{}"})
+		if reset {
+			// Too much was missed; the client should reload its task list instead.
+			c.Render(-1, sse.Event{Event: "reset", Data: "{}"})
+		}
+		for _, ev := range missed {
+			replayed[ev.ID] = true
+			send(c, ev)
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case ev, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				if replayed[ev.ID] {
+					continue
+				}
+				send(c, ev)
+			case <-heartbeat.C:
+				c.Writer.WriteString(": heartbeat\n\n")
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+//! \fn send(c *gin.Context, ev Event)
+//! \brief Writes one event to the stream.
+//! \param c Gin context.
+//! \param ev Event to write.
+func send(c *gin.Context, ev Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(ev.ID, 10),
+		Event: ev.Type,
+		Data:  string(ev.Data),
+	})
+}
@@ -0,0 +1,373 @@
+package tasks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \const maxBulkItems
+//! \brief Maximum number of tasks a single bulk request may touch.
+const maxBulkItems = 200
+
+//! \brief Bulk execution modes.
+const (
+	BulkAtomic  = "atomic"
+	BulkPerItem = "per_item"
+)
+
+//! \brief Bulk operation kinds.
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkPatch  = "patch"
+	BulkDelete = "delete"
+)
+
+//! \brief Actions applied to every task matched by a bulk filter.
+const (
+	BulkActionDone   = "done"
+	BulkActionPatch  = "patch"
+	BulkActionDelete = "delete"
+)
+
+//! \var ErrInvalidBulk
+//! \brief Returned when a bulk request or one of its operations is malformed.
+var ErrInvalidBulk = errors.New("invalid bulk request")
+
+//! \var ErrBulkTooLarge
+//! \brief Returned when a bulk request would touch more than maxBulkItems tasks.
+var ErrBulkTooLarge = fmt.Errorf("bulk requests are limited to %d tasks", maxBulkItems)
+
+//! \var ErrVersionRequired
+//! \brief Returned when a bulk operation lacks a version although If-Match is required.
+var ErrVersionRequired = errors.New("version required")
+
+//! \struct BulkOperation
+//! \brief A single create, update, patch or delete within a bulk request.
+type BulkOperation struct {
+	Op       string          `json:"op"`
+	ID       int             `json:"id,omitempty"`
+	Version  *int            `json:"version,omitempty"`
+	Task     *models.Task    `json:"task,omitempty"`
+	Patch    json.RawMessage `json:"patch,omitempty"`
+	Children string          `json:"children,omitempty"`
+
+	// filtered marks operations expanded from a filter; they are conditioned on the filter, not a version.
+	filtered bool
+	// omitted lists the optional fields an update's task left out.
+	omitted Omitted
+}
+
+//! \fn UnmarshalJSON(data []byte) error
+//! \brief Decodes a bulk operation, noting which optional fields its task omits.
+//! \param data JSON operation document.
+//! \return Error (if any).
+func (op *BulkOperation) UnmarshalJSON(data []byte) error {
+	type plain BulkOperation
+	var raw struct {
+		plain
+		Task json.RawMessage `json:"task"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*op = BulkOperation(raw.plain)
+	if len(raw.Task) == 0 || string(raw.Task) == "null" {
+		return nil
+	}
+	op.Task = &models.Task{}
+	if err := json.Unmarshal(raw.Task, op.Task); err != nil {
+		return err
+	}
+	op.omitted = OmittedFields(raw.Task)
+	return nil
+}
+
+//! \struct BulkFilter
+//! \brief Selects tasks for a bulk action with the same filters as listing tasks.
+type BulkFilter struct {
+	Status          []string   `json:"status"`
+	PriorityMin     *int       `json:"priority_min"`
+	PriorityMax     *int       `json:"priority_max"`
+	DueAfter        *time.Time `json:"due_after"`
+	DueBefore       *time.Time `json:"due_before"`
+	CreatedAfter    *time.Time `json:"created_after"`
+	CreatedBefore   *time.Time `json:"created_before"`
+	Labels          []string   `json:"labels"`
+	LabelMatch      string     `json:"label_match"`
+	ProjectID       *int       `json:"project_id"`
+	IncludeArchived bool       `json:"include_archived"`
+}
+
+//! \struct BulkRequest
+//! \brief Either a list of operations or a filter with an action, run in one transaction.
+type BulkRequest struct {
+	Mode       string          `json:"mode"`
+	Operations []BulkOperation `json:"operations"`
+	Filter     *BulkFilter     `json:"filter"`
+	Action     string          `json:"action"`
+	Patch      json.RawMessage `json:"patch"`
+	Children   string          `json:"children"`
+}
+
+//! \struct BulkResult
+//! \brief Outcome of one bulk operation.
+type BulkResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	TaskID  int    `json:"task_id,omitempty"`
+	Status  int    `json:"status"`
+	Version int    `json:"version,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+//! \fn validate() error
+//! \brief Checks the shape of a bulk request before any work is done.
+//! \return Error (if any).
+func (r *BulkRequest) validate() error {
+	switch r.Mode {
+	case "":
+		r.Mode = BulkAtomic
+	case BulkAtomic, BulkPerItem:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidBulk, r.Mode)
+	}
+
+	if (r.Filter == nil) == (len(r.Operations) == 0) {
+		return fmt.Errorf("%w: send either operations or a filter", ErrInvalidBulk)
+	}
+	if len(r.Operations) > maxBulkItems {
+		return ErrBulkTooLarge
+	}
+	if r.Filter == nil {
+		return nil
+	}
+
+	for _, status := range r.Filter.Status {
+		if !validStatuses[status] {
+			return fmt.Errorf("%w: invalid status %q", ErrInvalidBulk, status)
+		}
+	}
+	switch r.Action {
+	case BulkActionDone, BulkActionDelete:
+	case BulkActionPatch:
+		if len(r.Patch) == 0 {
+			return fmt.Errorf("%w: patch action needs a patch", ErrInvalidBulk)
+		}
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidBulk, r.Action)
+	}
+	return nil
+}
+
+//! \fn BulkTasks(userID int, req *BulkRequest) ([]BulkResult, bool, error)
+//! \brief Runs a batch of task operations in a single transaction. In atomic mode the first
+//!        failure rolls everything back; in per-item mode each operation runs in its own
+//!        savepoint and only the failed ones are undone.
+//! \param userID ID of the user.
+//! \param req Bulk request.
+//! \return Per-operation results, whether the transaction was committed, and error (if any).
+func (s *Service) BulkTasks(userID int, req *BulkRequest) ([]BulkResult, bool, error) {
+	if err := req.validate(); err != nil {
+		return nil, false, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	ops := req.Operations
+	if req.Filter != nil {
+		if ops, err = s.expandFilter(tx, userID, req); err != nil {
+			return nil, false, err
+		}
+	}
+
+	perItem := req.Mode == BulkPerItem
+	results := make([]BulkResult, 0, len(ops))
+	for i := range ops {
+		if perItem {
+			if _, err := tx.Exec(`SAVEPOINT bulk_item`); err != nil {
+				s.logger.Error("Failed to create savepoint", zap.Error(err))
+				return nil, false, err
+			}
+		}
+
+		result, err := s.runBulkOperation(tx, userID, &ops[i])
+		result.Index, result.Op = i, ops[i].Op
+		if err == nil {
+			results = append(results, result)
+			if perItem {
+				if _, err := tx.Exec(`RELEASE SAVEPOINT bulk_item`); err != nil {
+					s.logger.Error("Failed to release savepoint", zap.Error(err))
+					return nil, false, err
+				}
+			}
+			continue
+		}
+
+		result.Status, result.Error = describeError(err, "Internal server error")
+		results = append(results, result)
+		if !perItem {
+			s.logger.Warn("Bulk operation failed; rolling back", zap.Int("index", i), zap.Error(err))
+			return results, false, nil
+		}
+		if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT bulk_item`); err != nil {
+			s.logger.Error("Failed to roll back savepoint", zap.Error(err))
+			return nil, false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, false, err
+	}
+
+	s.logger.Info("Bulk operations applied", zap.Int("user_id", userID), zap.String("mode", req.Mode),
+		zap.Int("count", len(results)))
+	return results, true, nil
+}
+
+//! \fn expandFilter(tx *sql.Tx, userID int, req *BulkRequest) ([]BulkOperation, error)
+//! \brief Locks the tasks matched by a bulk filter and turns the action into one operation per task.
+//! \param tx Open transaction.
+//! \param userID ID of the user.
+//! \param req Validated bulk request with a filter.
+//! \return Operations and error (if any).
+func (s *Service) expandFilter(tx *sql.Tx, userID int, req *BulkRequest) ([]BulkOperation, error) {
+	f := req.Filter
+	opts := ListOptions{
+		Statuses:        f.Status,
+		PriorityMin:     f.PriorityMin,
+		PriorityMax:     f.PriorityMax,
+		DueAfter:        f.DueAfter,
+		DueBefore:       f.DueBefore,
+		CreatedAfter:    f.CreatedAfter,
+		CreatedBefore:   f.CreatedBefore,
+		Labels:          f.Labels,
+		LabelMatch:      f.LabelMatch,
+		ProjectID:       f.ProjectID,
+		IncludeArchived: f.IncludeArchived,
+	}
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+	for _, t := range []*time.Time{opts.DueAfter, opts.DueBefore, opts.CreatedAfter, opts.CreatedBefore} {
+		if t != nil {
+			*t = t.UTC()
+		}
+	}
+
+	b := &queryBuilder{}
+	filterConditions(b, userID, opts)
+	query := `SELECT id FROM tasks WHERE ` + b.clause() + ` ORDER BY id LIMIT ` + b.arg(maxBulkItems+1) + ` FOR UPDATE`
+	ids, err := queryIDs(tx, query, b.args...)
+	if err != nil {
+		s.logger.Error("Failed to select tasks for bulk action", zap.Error(err))
+		return nil, err
+	}
+	if len(ids) > maxBulkItems {
+		return nil, ErrBulkTooLarge
+	}
+
+	ops := make([]BulkOperation, len(ids))
+	for i, id := range ids {
+		op := BulkOperation{ID: id, filtered: true}
+		switch req.Action {
+		case BulkActionDone:
+			op.Op, op.Patch = BulkPatch, json.RawMessage(`{"status": "done"}`)
+		case BulkActionPatch:
+			op.Op, op.Patch = BulkPatch, req.Patch
+		case BulkActionDelete:
+			op.Op, op.Children = BulkDelete, req.Children
+		}
+		ops[i] = op
+	}
+	return ops, nil
+}
+
+//! \fn runBulkOperation(tx *sql.Tx, userID int, op *BulkOperation) (BulkResult, error)
+//! \brief Applies one bulk operation with the same checks as the single-task endpoints.
+//! \param tx Open transaction.
+//! \param userID ID of the user.
+//! \param op Operation to apply.
+//! \return Result and error (if any).
+func (s *Service) runBulkOperation(tx *sql.Tx, userID int, op *BulkOperation) (BulkResult, error) {
+	result := BulkResult{TaskID: op.ID, Status: http.StatusOK}
+	if op.Op == BulkCreate {
+		if op.Task == nil {
+			return result, fmt.Errorf("%w: create needs a task", ErrInvalidBulk)
+		}
+		if err := validateTask(op.Task); err != nil {
+			return result, err
+		}
+		op.Task.UserID = userID
+		taskID, err := s.createTask(tx, op.Task)
+		if err != nil {
+			return result, err
+		}
+		result.TaskID, result.Status = taskID, http.StatusCreated
+		return result, nil
+	}
+
+	if op.ID <= 0 {
+		return result, fmt.Errorf("%w: %s needs a task id", ErrInvalidBulk, op.Op)
+	}
+	var cond Precondition
+	if op.Version != nil {
+		cond = Precondition{*op.Version}
+	} else if s.requireIfMatch && !op.filtered {
+		return result, ErrVersionRequired
+	}
+	taskID := strconv.Itoa(op.ID)
+
+	var err error
+	switch op.Op {
+	case BulkUpdate:
+		if op.Task == nil {
+			return result, fmt.Errorf("%w: update needs a task", ErrInvalidBulk)
+		}
+		if err := validateTask(op.Task); err != nil {
+			return result, err
+		}
+		err = s.updateTask(tx, op.Task, taskID, userID, cond, op.omitted)
+		result.Version = op.Task.Version
+
+	case BulkPatch:
+		if len(op.Patch) == 0 {
+			return result, fmt.Errorf("%w: patch needs a patch", ErrInvalidBulk)
+		}
+		var task *models.Task
+		if task, err = s.patchTask(tx, taskID, userID, op.Patch, cond); err == nil {
+			result.Version = task.Version
+		}
+
+	case BulkDelete:
+		var policy DeletePolicy
+		if policy, err = ParseDeletePolicy(op.Children); err == nil {
+			err = s.deleteTask(tx, taskID, userID, cond, policy)
+		}
+
+	default:
+		return result, fmt.Errorf("%w: unknown op %q", ErrInvalidBulk, op.Op)
+	}
+
+	// A cascading delete earlier in a filtered batch may already have trashed this task.
+	if op.filtered && isNotFound(err) {
+		result.Skipped = true
+		return result, nil
+	}
+	return result, err
+}
@@ -0,0 +1,78 @@
+package tasks
+
+import (
+	"errors"
+	"net/http"
+
+	"task-tracker/internal/rrule"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+//! \var errorStatuses
+//! \brief HTTP statuses of the task domain errors that are safe to show to clients.
+var errorStatuses = map[error]int{
+	ErrInvalidCursor:          http.StatusBadRequest,
+	ErrInvalidSort:            http.StatusBadRequest,
+	ErrEmptyQuery:             http.StatusBadRequest,
+	ErrInvalidPatch:           http.StatusBadRequest,
+	ErrInvalidParent:          http.StatusBadRequest,
+	ErrInvalidProject:         http.StatusBadRequest,
+	ErrInvalidDeletePolicy:    http.StatusBadRequest,
+	ErrParentCycle:            http.StatusConflict,
+	ErrHasChildren:            http.StatusConflict,
+	ErrParentTrashed:          http.StatusConflict,
+	ErrProjectArchived:        http.StatusConflict,
+	ErrInvalidLink:            http.StatusBadRequest,
+	ErrLinkCycle:              http.StatusConflict,
+	ErrLinkExists:             http.StatusConflict,
+	ErrBlocked:                http.StatusConflict,
+	ErrInvalidLabel:           http.StatusBadRequest,
+	ErrInvalidLabelMatch:      http.StatusBadRequest,
+	rrule.ErrInvalidRule:      http.StatusBadRequest,
+	ErrRecurrenceNeedsDueDate: http.StatusBadRequest,
+	ErrInvalidBulk:            http.StatusBadRequest,
+	ErrBulkTooLarge:           http.StatusRequestEntityTooLarge,
+	ErrInvalidFormat:          http.StatusBadRequest,
+	ErrInvalidImport:          http.StatusBadRequest,
+	ErrPreconditionFailed:     http.StatusPreconditionFailed,
+	ErrVersionRequired:        http.StatusPreconditionRequired,
+}
+
+//! \fn describeError(err error, message string) (int, string)
+//! \brief Maps a failed task operation to its HTTP status and client-facing message.
+//! \param err Error returned by the service.
+//! \param message Message used for unexpected errors.
+//! \return HTTP status and message.
+func describeError(err error, message string) (int, string) {
+	if isNotFound(err) {
+		return http.StatusNotFound, "Task not found"
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return http.StatusBadRequest, err.Error()
+	}
+	for known, status := range errorStatuses {
+		if errors.Is(err, known) {
+			return status, err.Error()
+		}
+	}
+	return http.StatusInternalServerError, message
+}
+
+//! \fn respondError(c *gin.Context, s *Service, err error, message string)
+//! \brief Writes the response for a failed task operation.
+//! \param c Gin context.
+//! \param s Task service instance.
+//! \param err Error returned by the service.
+//! \param message Message used for unexpected errors.
+func respondError(c *gin.Context, s *Service, err error, message string) {
+	status, text := describeError(err, message)
+	if status == http.StatusInternalServerError {
+		s.logger.Error(message, zap.Error(err))
+	} else {
+		s.logger.Warn(message, zap.Error(err))
+	}
+	c.JSON(status, gin.H{"error": text})
+}
@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//! \var ErrPreconditionFailed
+//! \brief Returned when a task's version does not match the client's If-Match header.
+var ErrPreconditionFailed = errors.New("task has been modified")
+
+//! \type Precondition
+//! \brief Task versions a client expects; nil means the mutation is unconditional.
+type Precondition []int
+
+//! \fn allows(version int) bool
+//! \brief Reports whether the current task version satisfies the precondition.
+//! \param version Current task version.
+//! \return True if the mutation may proceed.
+func (p Precondition) allows(version int) bool {
+	if p == nil {
+		return true
+	}
+	for _, v := range p {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+//! \fn etag(version int) string
+//! \brief Formats a task version as a strong entity tag.
+//! \param version Task version.
+//! \return Quoted entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+//! \fn parseIfMatch(header string) Precondition
+//! \brief Parses an If-Match header using strong comparison (RFC 7232).
+//! \param header Raw header value.
+//! \return Precondition; nil for "*", empty if no listed tag can match.
+func parseIfMatch(header string) Precondition {
+	cond := Precondition{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil
+		}
+		// Weak tags never match under strong comparison.
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if v, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil {
+			cond = append(cond, v)
+		}
+	}
+	return cond
+}
+
+//! \fn preconditionFrom(c *gin.Context, s *Service) (Precondition, bool)
+//! \brief Reads the If-Match precondition, answering 428 if it is required but missing.
+//! \param c Gin context.
+//! \param s Task service instance.
+//! \return Precondition and false if a response has already been written.
+func preconditionFrom(c *gin.Context, s *Service) (Precondition, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if s.requireIfMatch {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header required"})
+			return nil, false
+		}
+		return nil, true
+	}
+	return parseIfMatch(header), true
+}
@@ -0,0 +1,97 @@
+package tasks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"task-tracker/internal/events"
+	"task-tracker/internal/models"
+	"task-tracker/internal/webhooks"
+)
+
+//! \var eventTypes
+//! \brief Maps history actions to outgoing event types; every other action is an update.
+var eventTypes = map[string]string{
+	ActionCreated: webhooks.EventTaskCreated,
+	ActionDeleted: webhooks.EventTaskDeleted,
+	ActionPurged:  webhooks.EventTaskDeleted,
+}
+
+//! \fn EventType(action string) string
+//! \brief Maps a history action to its outgoing event type.
+//! \param action History action.
+//! \return Event type such as "task.updated".
+func EventType(action string) string {
+	if t, ok := eventTypes[action]; ok {
+		return t
+	}
+	return webhooks.EventTaskUpdated
+}
+
+//! \struct Event
+//! \brief Payload of an outgoing task event.
+type Event struct {
+	Event      string                        `json:"event"`
+	Action     string                        `json:"action"`
+	ActorID    int                           `json:"actor_id"`
+	OccurredAt time.Time                     `json:"occurred_at"`
+	Task       models.Task                   `json:"task"`
+	Changes    map[string]models.FieldChange `json:"changes"`
+}
+
+//! \struct busData
+//! \brief Data of a task event on the event bus; subscribers load the rest by history ID.
+type busData struct {
+	TaskID  int    `json:"task_id"`
+	Action  string `json:"action"`
+	ActorID int    `json:"actor_id"`
+}
+
+//! \fn publishEvent(tx *sql.Tx, eventID int64, taskID, actorID int, action string, changes map[string]models.FieldChange) error
+//! \brief Emits the outgoing webhook and bus events for a history entry within the caller's transaction,
+//!        carrying the task as it stands after the change.
+//! \param tx Open transaction performing the change.
+//! \param eventID ID of the history entry.
+//! \param taskID ID of the task.
+//! \param actorID ID of the user making the change.
+//! \param action Kind of change.
+//! \param changes Field-level diff.
+//! \return Error (if any).
+func (s *Service) publishEvent(tx *sql.Tx, eventID int64, taskID, actorID int, action string, changes map[string]models.FieldChange) error {
+	var task models.Task
+	if err := scanTask(tx.QueryRow(`SELECT `+taskColumns+` FROM tasks WHERE id = $1`, taskID), &task); err != nil {
+		return err
+	}
+	return s.publishTask(tx, eventID, &task, actorID, action, changes)
+}
+
+//! \fn publishTask(tx *sql.Tx, eventID int64, task *models.Task, actorID int, action string, changes map[string]models.FieldChange) error
+//! \brief Emits the outgoing webhook and bus events for a history entry with a given task state,
+//!        e.g. the last one of a task about to be purged.
+//! \param tx Open transaction performing the change.
+//! \param eventID ID of the history entry.
+//! \param task Task carried by the event.
+//! \param actorID ID of the user making the change; 0 for the system.
+//! \param action Kind of change.
+//! \param changes Field-level diff.
+//! \return Error (if any).
+func (s *Service) publishTask(tx *sql.Tx, eventID int64, task *models.Task, actorID int, action string, changes map[string]models.FieldChange) error {
+	taskID := task.ID
+	event := Event{Action: action, ActorID: actorID, OccurredAt: time.Now().UTC(), Task: *task, Changes: changes}
+	event.Event = EventType(action)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := webhooks.Enqueue(tx, event.Task.UserID, event.Event, payload); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(busData{TaskID: taskID, Action: action, ActorID: actorID})
+	if err != nil {
+		return err
+	}
+	return s.bus.PublishTx(tx, events.Event{Type: event.Event, UserID: event.Task.UserID, ID: eventID, Data: data})
+}
@@ -0,0 +1,175 @@
+package tasks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \brief Actions recorded in the task history.
+const (
+	ActionCreated   = "created"
+	ActionUpdated   = "updated"
+	ActionDeleted   = "deleted"
+	ActionRestored  = "restored"
+	ActionPurged    = "purged"
+	ActionLinked    = "linked"
+	ActionUnlinked  = "unlinked"
+	ActionLabeled   = "labeled"
+	ActionUnlabeled = "unlabeled"
+)
+
+//! \fn diffTasks(before, after *models.Task) map[string]models.FieldChange
+//! \brief Computes the field-level changes between two versions of a task.
+//! \param before Previous task state; nil for a newly created task.
+//! \param after New task state.
+//! \return Changed fields keyed by their JSON name.
+func diffTasks(before, after *models.Task) map[string]models.FieldChange {
+	if before == nil {
+		before = &models.Task{}
+	}
+	changes := map[string]models.FieldChange{}
+	if before.Title != after.Title {
+		changes["title"] = models.FieldChange{From: before.Title, To: after.Title}
+	}
+	if before.Description != after.Description {
+		changes["description"] = models.FieldChange{From: before.Description, To: after.Description}
+	}
+	if before.Status != after.Status {
+		changes["status"] = models.FieldChange{From: before.Status, To: after.Status}
+	}
+	if before.Priority != after.Priority {
+		changes["priority"] = models.FieldChange{From: before.Priority, To: after.Priority}
+	}
+	if !equalTimes(before.DueDate, after.DueDate) {
+		changes["due_date"] = models.FieldChange{From: before.DueDate, To: after.DueDate}
+	}
+	if before.ProjectID != after.ProjectID {
+		changes["project_id"] = models.FieldChange{From: before.ProjectID, To: after.ProjectID}
+	}
+	if !equalIDs(before.ParentID, after.ParentID) {
+		changes["parent_id"] = models.FieldChange{From: before.ParentID, To: after.ParentID}
+	}
+	if !equalStrings(before.Recurrence, after.Recurrence) {
+		changes["recurrence"] = models.FieldChange{From: before.Recurrence, To: after.Recurrence}
+	}
+	return changes
+}
+
+//! \fn recordEvent(tx *sql.Tx, taskID, actorID int, action string, changes map[string]models.FieldChange) error
+//! \brief Appends an entry to a task's history and emits the matching event within the caller's transaction.
+//! \param tx Open transaction performing the change.
+//! \param taskID ID of the task.
+//! \param actorID ID of the user making the change.
+//! \param action Kind of change.
+//! \param changes Field-level diff.
+//! \return Error (if any).
+func (s *Service) recordEvent(tx *sql.Tx, taskID, actorID int, action string, changes map[string]models.FieldChange) error {
+	if changes == nil {
+		changes = map[string]models.FieldChange{}
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	var eventID int64
+	query := `INSERT INTO task_events (task_id, user_id, actor_id, action, changes)
+              SELECT id, user_id, $2, $3, $4 FROM tasks WHERE id = $1 RETURNING id`
+	if err := tx.QueryRow(query, taskID, actorID, action, data).Scan(&eventID); err != nil {
+		return err
+	}
+	return s.publishEvent(tx, eventID, taskID, actorID, action, changes)
+}
+
+//! \fn GetHistory(taskID string, userID int) ([]models.TaskEvent, error)
+//! \brief Retrieves the change history of a user's task, oldest first.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \return List of events and error (if any).
+func (s *Service) GetHistory(taskID string, userID int) ([]models.TaskEvent, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2)`
+	if err := s.db.QueryRow(query, taskID, userID).Scan(&exists); err != nil {
+		s.logger.Error("Failed to check task ownership", zap.Error(err))
+		return nil, err
+	}
+	if !exists {
+		s.logger.Warn("Task not found", zap.String("task_id", taskID))
+		return nil, sql.ErrNoRows
+	}
+
+	query = `SELECT id, task_id, actor_id, action, changes, created_at
+             FROM task_events WHERE task_id = $1 ORDER BY id`
+	rows, err := s.db.Query(query, taskID)
+	if err != nil {
+		s.logger.Error("Failed to fetch task history", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.TaskEvent{}
+	for rows.Next() {
+		var event models.TaskEvent
+		var actorID sql.NullInt64
+		var changes []byte
+		if err := rows.Scan(&event.ID, &event.TaskID, &actorID, &event.Action, &changes, &event.CreatedAt); err != nil {
+			s.logger.Error("Failed to scan task event", zap.Error(err))
+			return nil, err
+		}
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			event.ActorID = &id
+		}
+		if err := json.Unmarshal(changes, &event.Changes); err != nil {
+			s.logger.Error("Failed to decode task event changes", zap.Error(err))
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate task history", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Task history retrieved", zap.String("task_id", taskID), zap.Int("count", len(events)))
+	return events, nil
+}
+
+//! \fn equalIDs(a, b *int) bool
+//! \brief Compares two optional IDs.
+//! \param a First ID.
+//! \param b Second ID.
+//! \return True if both are unset or hold the same value.
+func equalIDs(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+//! \fn equalTimes(a, b *time.Time) bool
+//! \brief Compares two optional instants.
+//! \param a First instant.
+//! \param b Second instant.
+//! \return True if both are unset or denote the same instant.
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+//! \fn equalStrings(a, b *string) bool
+//! \brief Compares two optional strings.
+//! \param a First string.
+//! \param b Second string.
+//! \return True if both are unset or hold the same value.
+func equalStrings(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
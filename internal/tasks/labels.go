@@ -0,0 +1,144 @@
+package tasks
+
+import (
+	"database/sql"
+	"errors"
+
+	"task-tracker/internal/models"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+//! \brief Label filter matching modes.
+const (
+	LabelMatchAny = "any"
+	LabelMatchAll = "all"
+)
+
+//! \var ErrInvalidLabel
+//! \brief Returned when a label does not exist or belongs to another user.
+var ErrInvalidLabel = errors.New("label not found")
+
+//! \var ErrInvalidLabelMatch
+//! \brief Returned when an unknown label matching mode is requested.
+var ErrInvalidLabelMatch = errors.New("invalid label_match: expected any or all")
+
+//! \fn AttachLabel(taskID, labelID string, userID int) error
+//! \brief Attaches one of the user's labels to their task.
+//! \param taskID ID of the task.
+//! \param labelID ID of the label.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) AttachLabel(taskID, labelID string, userID int) error {
+	return s.changeLabel(taskID, labelID, userID, true)
+}
+
+//! \fn DetachLabel(taskID, labelID string, userID int) error
+//! \brief Removes a label from a user's task.
+//! \param taskID ID of the task.
+//! \param labelID ID of the label.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) DetachLabel(taskID, labelID string, userID int) error {
+	return s.changeLabel(taskID, labelID, userID, false)
+}
+
+//! \fn changeLabel(taskID, labelID string, userID int, attach bool) error
+//! \brief Attaches or detaches a label and records the change in the task history.
+//! \param taskID ID of the task.
+//! \param labelID ID of the label.
+//! \param userID ID of the user.
+//! \param attach True to attach, false to detach.
+//! \return Error (if any).
+func (s *Service) changeLabel(taskID, labelID string, userID int, attach bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	task, err := lockTask(tx, taskID, userID)
+	if err != nil {
+		s.logger.Warn("Task not found", zap.String("task_id", taskID), zap.Error(err))
+		return err
+	}
+
+	var name string
+	query := `SELECT name FROM labels WHERE id = $1 AND user_id = $2`
+	err = tx.QueryRow(query, labelID, userID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return ErrInvalidLabel
+	}
+	if err != nil {
+		s.logger.Error("Failed to fetch label", zap.Error(err))
+		return err
+	}
+
+	action, change := ActionLabeled, models.FieldChange{From: nil, To: name}
+	query = `INSERT INTO task_labels (task_id, label_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if !attach {
+		action, change = ActionUnlabeled, models.FieldChange{From: name, To: nil}
+		query = `DELETE FROM task_labels WHERE task_id = $1 AND label_id = $2`
+	}
+	result, err := tx.Exec(query, task.ID, labelID)
+	if err != nil {
+		s.logger.Error("Failed to change task label", zap.Error(err))
+		return err
+	}
+
+	// Attaching an attached label or detaching a missing one is a no-op.
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		if err := s.recordEvent(tx, task.ID, userID, action, map[string]models.FieldChange{"labels": change}); err != nil {
+			s.logger.Error("Failed to record task event", zap.Error(err))
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Task label changed", zap.String("task_id", taskID), zap.String("label_id", labelID),
+		zap.Bool("attached", attach))
+	return nil
+}
+
+//! \fn loadLabels(tasks []models.Task) error
+//! \brief Fills in the labels of a batch of tasks with a single query.
+//! \param tasks Tasks to annotate.
+//! \return Error (if any).
+func (s *Service) loadLabels(tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(tasks))
+	index := make(map[int]int, len(tasks))
+	for i := range tasks {
+		ids[i] = int64(tasks[i].ID)
+		index[tasks[i].ID] = i
+		tasks[i].Labels = []models.Label{}
+	}
+
+	query := `SELECT tl.task_id, l.id, l.user_id, l.name, l.color, l.created_at
+              FROM task_labels tl JOIN labels l ON l.id = tl.label_id
+              WHERE tl.task_id = ANY($1) ORDER BY l.name`
+	rows, err := s.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int
+		var label models.Label
+		if err := rows.Scan(&taskID, &label.ID, &label.UserID, &label.Name, &label.Color, &label.CreatedAt); err != nil {
+			return err
+		}
+		if i, ok := index[taskID]; ok {
+			tasks[i].Labels = append(tasks[i].Labels, label)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,250 @@
+package tasks
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \brief Task link types.
+const (
+	LinkBlocks      = "blocks"
+	LinkRelatesTo   = "relates_to"
+	LinkDuplicateOf = "duplicate_of"
+)
+
+//! \var ErrInvalidLink
+//! \brief Returned when a link targets the task itself or a task the user cannot see.
+var ErrInvalidLink = errors.New("invalid link target")
+
+//! \var ErrLinkCycle
+//! \brief Returned when a blocking link would make a task transitively block itself.
+var ErrLinkCycle = errors.New("link would create a blocking cycle")
+
+//! \var ErrLinkExists
+//! \brief Returned when an identical link already exists.
+var ErrLinkExists = errors.New("link already exists")
+
+//! \var ErrBlocked
+//! \brief Returned when a task is marked done while its blockers are still open.
+var ErrBlocked = errors.New("task is blocked by open tasks")
+
+//! \fn AddLink(taskID string, userID int, link *models.TaskLink) error
+//! \brief Links a user's task to another of their tasks.
+//! \param taskID ID of the source task.
+//! \param userID ID of the user.
+//! \param link Link to create; receives its ID, source and creation time.
+//! \return Error (if any).
+func (s *Service) AddLink(taskID string, userID int, link *models.TaskLink) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	// Serializes link changes per user so concurrent inserts cannot form a cycle.
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, userID); err != nil {
+		s.logger.Error("Failed to lock links", zap.Error(err))
+		return err
+	}
+
+	source, err := lockTask(tx, taskID, userID)
+	if err != nil {
+		s.logger.Warn("Task not found", zap.String("task_id", taskID), zap.Error(err))
+		return err
+	}
+	if link.TargetID == source.ID {
+		return ErrInvalidLink
+	}
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+	if err := tx.QueryRow(query, link.TargetID, userID).Scan(&exists); err != nil {
+		s.logger.Error("Failed to check link target", zap.Error(err))
+		return err
+	}
+	if !exists {
+		return ErrInvalidLink
+	}
+
+	if link.Type == LinkBlocks {
+		var cycle bool
+		query = `WITH RECURSIVE reach AS (
+                     SELECT target_id FROM task_links WHERE source_id = $1 AND type = 'blocks'
+                     UNION
+                     SELECT l.target_id FROM task_links l JOIN reach r ON l.source_id = r.target_id
+                     WHERE l.type = 'blocks'
+                 )
+                 SELECT EXISTS (SELECT 1 FROM reach WHERE target_id = $2)`
+		if err := tx.QueryRow(query, link.TargetID, source.ID).Scan(&cycle); err != nil {
+			s.logger.Error("Failed to check link cycle", zap.Error(err))
+			return err
+		}
+		if cycle {
+			return ErrLinkCycle
+		}
+	}
+
+	query = `INSERT INTO task_links (source_id, target_id, type) VALUES ($1, $2, $3)
+             ON CONFLICT (source_id, target_id, type) DO NOTHING
+             RETURNING id, created_at`
+	err = tx.QueryRow(query, source.ID, link.TargetID, link.Type).Scan(&link.ID, &link.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrLinkExists
+	}
+	if err != nil {
+		s.logger.Error("Failed to create link", zap.Error(err))
+		return err
+	}
+	link.SourceID = source.ID
+
+	change := map[string]models.FieldChange{link.Type: {From: nil, To: link.TargetID}}
+	if err := s.recordEvent(tx, source.ID, userID, ActionLinked, change); err != nil {
+		s.logger.Error("Failed to record task event", zap.Error(err))
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Task linked", zap.Int("source_id", source.ID), zap.Int("target_id", link.TargetID),
+		zap.String("type", link.Type))
+	return nil
+}
+
+//! \fn RemoveLink(taskID, linkID string, userID int) error
+//! \brief Removes a link from a user's task.
+//! \param taskID ID of the source task.
+//! \param linkID ID of the link.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) RemoveLink(taskID, linkID string, userID int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	var link models.TaskLink
+	query := `DELETE FROM task_links l USING tasks t
+              WHERE l.id = $1 AND l.source_id = $2 AND t.id = l.source_id AND t.user_id = $3
+              RETURNING l.source_id, l.target_id, l.type`
+	err = tx.QueryRow(query, linkID, taskID, userID).Scan(&link.SourceID, &link.TargetID, &link.Type)
+	if err != nil {
+		s.logger.Warn("Link not found", zap.String("link_id", linkID), zap.Error(err))
+		return err
+	}
+
+	change := map[string]models.FieldChange{link.Type: {From: link.TargetID, To: nil}}
+	if err := s.recordEvent(tx, link.SourceID, userID, ActionUnlinked, change); err != nil {
+		s.logger.Error("Failed to record task event", zap.Error(err))
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Task unlinked", zap.String("task_id", taskID), zap.String("link_id", linkID))
+	return nil
+}
+
+//! \fn GetLinks(taskID string, userID int) ([]models.TaskLink, error)
+//! \brief Retrieves all links from or to a user's task.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \return List of links and error (if any).
+func (s *Service) GetLinks(taskID string, userID int) ([]models.TaskLink, error) {
+	if _, err := s.GetTask(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, source_id, target_id, type, created_at FROM task_links
+              WHERE source_id = $1 OR target_id = $1 ORDER BY id`
+	rows, err := s.db.Query(query, taskID)
+	if err != nil {
+		s.logger.Error("Failed to fetch links", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.TaskLink{}
+	for rows.Next() {
+		var link models.TaskLink
+		if err := rows.Scan(&link.ID, &link.SourceID, &link.TargetID, &link.Type, &link.CreatedAt); err != nil {
+			s.logger.Error("Failed to scan link", zap.Error(err))
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate links", zap.Error(err))
+		return nil, err
+	}
+	return links, nil
+}
+
+//! \fn loadBlocking(task *models.Task) error
+//! \brief Fills in the live tasks blocking and blocked by a task.
+//! \param task Task to annotate.
+//! \return Error (if any).
+func (s *Service) loadBlocking(task *models.Task) error {
+	query := `SELECT t.id, t.title, t.status, l.target_id = $1 AS is_blocker
+              FROM task_links l
+              JOIN tasks t ON t.id = CASE WHEN l.target_id = $1 THEN l.source_id ELSE l.target_id END
+              WHERE l.type = 'blocks' AND (l.source_id = $1 OR l.target_id = $1) AND t.deleted_at IS NULL
+              ORDER BY t.id`
+	rows, err := s.db.Query(query, task.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	task.BlockedBy = []models.TaskRef{}
+	task.Blocks = []models.TaskRef{}
+	for rows.Next() {
+		var ref models.TaskRef
+		var isBlocker bool
+		if err := rows.Scan(&ref.ID, &ref.Title, &ref.Status, &isBlocker); err != nil {
+			return err
+		}
+		if isBlocker {
+			task.BlockedBy = append(task.BlockedBy, ref)
+		} else {
+			task.Blocks = append(task.Blocks, ref)
+		}
+	}
+	return rows.Err()
+}
+
+//! \fn checkUnblocked(tx *sql.Tx, taskID int) error
+//! \brief Verifies that no live, unfinished task blocks the given task.
+//! \param tx Open transaction.
+//! \param taskID ID of the task.
+//! \return ErrBlocked naming the open blockers, or another error (if any).
+func checkUnblocked(tx *sql.Tx, taskID int) error {
+	query := `SELECT t.id FROM task_links l JOIN tasks t ON t.id = l.source_id
+              WHERE l.target_id = $1 AND l.type = 'blocks' AND t.deleted_at IS NULL AND t.status <> 'done'
+              ORDER BY t.id`
+	ids, err := queryIDs(tx, query, taskID)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	open := make([]string, len(ids))
+	for i, id := range ids {
+		open[i] = fmt.Sprint(id)
+	}
+	return fmt.Errorf("%w: %s", ErrBlocked, strings.Join(open, ", "))
+}
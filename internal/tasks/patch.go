@@ -0,0 +1,183 @@
+package tasks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \var ErrInvalidPatch
+//! \brief Returned when a merge patch document cannot be applied to a task.
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+//! \fn mergePatch(target, patch interface{}) interface{}
+//! \brief Applies a JSON Merge Patch (RFC 7396) to a decoded JSON document.
+//! \param target Original document.
+//! \param patch Patch document.
+//! \return Patched document.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+			continue
+		}
+		targetObj[name] = mergePatch(targetObj[name], value)
+	}
+	return targetObj
+}
+
+//! \struct Omitted
+//! \brief Optional fields a full update left out of its body; these keep their stored value.
+type Omitted struct {
+	ParentID   bool
+	Recurrence bool
+}
+
+//! \fn OmittedFields(body []byte) Omitted
+//! \brief Reports which optional task fields are absent from a JSON request body.
+//! \param body JSON task document.
+//! \return Set of omitted fields.
+func OmittedFields(body []byte) Omitted {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return Omitted{}
+	}
+	_, parent := fields["parent_id"]
+	_, recurrence := fields["recurrence"]
+	return Omitted{ParentID: !parent, Recurrence: !recurrence}
+}
+
+//! \fn keep(current, next *models.Task)
+//! \brief Copies the stored value of every omitted field into the new task state.
+//! \param current Task as currently stored.
+//! \param next New task state.
+func (o Omitted) keep(current, next *models.Task) {
+	if o.ParentID {
+		next.ParentID = current.ParentID
+	}
+	if o.Recurrence {
+		next.Recurrence = current.Recurrence
+	}
+}
+
+//! \fn applyTaskPatch(task *models.Task, patch []byte) (*models.Task, error)
+//! \brief Produces the result of merging a patch document into a task.
+//! \param task Current task.
+//! \param patch Merge patch document.
+//! \return Merged task and error (if any).
+func applyTaskPatch(task *models.Task, patch []byte) (*models.Task, error) {
+	var patchDoc interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	current, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(current, &doc); err != nil {
+		return nil, err
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, patchDoc))
+	if err != nil {
+		return nil, err
+	}
+	var result models.Task
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	// Identity and bookkeeping fields are not client-writable.
+	result.ID = task.ID
+	result.UserID = task.UserID
+	result.CreatedAt = task.CreatedAt
+	result.Version = task.Version
+	result.DeletedAt = task.DeletedAt
+	result.Progress = task.Progress
+	result.BlockedBy, result.Blocks = nil, nil
+	result.Labels = nil
+	return &result, nil
+}
+
+//! \fn PatchTask(taskID string, userID int, patch []byte, cond Precondition) (*models.Task, error)
+//! \brief Applies a JSON Merge Patch to a task, validating the merged result.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \param patch Merge patch document.
+//! \param cond Versions the client expects the task to be at.
+//! \return Updated task and error (if any).
+func (s *Service) PatchTask(taskID string, userID int, patch []byte, cond Precondition) (*models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	merged, err := s.patchTask(tx, taskID, userID, patch, cond)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Task patched", zap.String("task_id", taskID), zap.Int("user_id", userID))
+	return merged, nil
+}
+
+//! \fn patchTask(tx *sql.Tx, taskID string, userID int, patch []byte, cond Precondition) (*models.Task, error)
+//! \brief Locks a task, checks the precondition and applies a merge patch to it.
+//! \param tx Open transaction.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \param patch Merge patch document.
+//! \param cond Versions the client expects the task to be at.
+//! \return Updated task and error (if any).
+func (s *Service) patchTask(tx *sql.Tx, taskID string, userID int, patch []byte, cond Precondition) (*models.Task, error) {
+	task, err := lockTask(tx, taskID, userID)
+	if err != nil {
+		s.logger.Warn("Task not found", zap.Error(err))
+		return nil, err
+	}
+	if !cond.allows(task.Version) {
+		s.logger.Warn("Task version mismatch", zap.String("task_id", taskID), zap.Int("version", task.Version))
+		return nil, ErrPreconditionFailed
+	}
+
+	merged, err := applyTaskPatch(task, patch)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTask(merged); err != nil {
+		return nil, err
+	}
+
+	if err := s.applyUpdate(tx, task, merged, userID); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+//! \fn isNotFound(err error) bool
+//! \brief Reports whether an error means the task does not exist for the user.
+//! \param err Error to inspect.
+//! \return True if the task was not found.
+func isNotFound(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
@@ -0,0 +1,149 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"task-tracker/internal/models"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples of RFC 7396 appendix A.
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		var target, patch interface{}
+		if err := json.Unmarshal([]byte(tt.target), &target); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(mergePatch(target, patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("mergePatch(%s, %s) = %s, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyTaskPatch(t *testing.T) {
+	due := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	parent := 4
+	rule := "FREQ=WEEKLY"
+	task := models.Task{
+		ID: 7, UserID: 2, Title: "Write report", Status: "pending", Priority: 2, DueDate: &due,
+		CreatedAt: created, Version: 3, ParentID: &parent, ProjectID: 5, Recurrence: &rule,
+	}
+	tests := []struct {
+		name  string
+		patch string
+		check func(t *testing.T, got *models.Task)
+	}{
+		{"empty patch", `{}`, func(t *testing.T, got *models.Task) {
+			if got.Title != task.Title || got.DueDate == nil || !got.DueDate.Equal(due) || got.Recurrence == nil {
+				t.Errorf("empty patch changed the task: %+v", got)
+			}
+		}},
+		{"set title", `{"title":"Send report"}`, func(t *testing.T, got *models.Task) {
+			if got.Title != "Send report" || got.Priority != task.Priority {
+				t.Errorf("got title %q, priority %d", got.Title, got.Priority)
+			}
+		}},
+		{"null clears due date", `{"due_date":null}`, func(t *testing.T, got *models.Task) {
+			if got.DueDate != nil {
+				t.Errorf("got due_date %v, want nil", got.DueDate)
+			}
+		}},
+		{"null moves to top level", `{"parent_id":null}`, func(t *testing.T, got *models.Task) {
+			if got.ParentID != nil {
+				t.Errorf("got parent_id %d, want nil", *got.ParentID)
+			}
+		}},
+		{"null clears recurrence", `{"recurrence":null}`, func(t *testing.T, got *models.Task) {
+			if got.Recurrence != nil {
+				t.Errorf("got recurrence %q, want nil", *got.Recurrence)
+			}
+		}},
+		{"bookkeeping is not writable", `{"id":9,"user_id":3,"version":8,"created_at":"2020-01-01T00:00:00Z"}`,
+			func(t *testing.T, got *models.Task) {
+				if got.ID != task.ID || got.UserID != task.UserID || got.Version != task.Version ||
+					!got.CreatedAt.Equal(created) {
+					t.Errorf("bookkeeping fields changed: %+v", got)
+				}
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyTaskPatch(&task, []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("applyTaskPatch(%s) error = %v", tt.patch, err)
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestApplyTaskPatchInvalid(t *testing.T) {
+	task := models.Task{ID: 7, Title: "Write report", Status: "pending", Priority: 1}
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"malformed", `{"title":`},
+		{"wrong type", `{"priority":"high"}`},
+		{"not an object", `["title"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := applyTaskPatch(&task, []byte(tt.patch)); !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("applyTaskPatch(%s) error = %v, want ErrInvalidPatch", tt.patch, err)
+			}
+		})
+	}
+}
+
+func TestOmittedFields(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Omitted
+	}{
+		{"both omitted", `{"title":"a"}`, Omitted{ParentID: true, Recurrence: true}},
+		{"null parent", `{"parent_id":null}`, Omitted{Recurrence: true}},
+		{"recurrence given", `{"recurrence":"FREQ=DAILY"}`, Omitted{ParentID: true}},
+		{"both given", `{"parent_id":3,"recurrence":null}`, Omitted{}},
+		{"not an object", `[]`, Omitted{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OmittedFields([]byte(tt.body)); got != tt.want {
+				t.Errorf("OmittedFields(%s) = %+v, want %+v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package tasks
+
+import (
+	"database/sql"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \fn MoveProjectTasks(tx *sql.Tx, fromID, toID, userID int) error
+//! \brief Moves every task of a project, including trashed ones, to another project and records
+//!        the move in each task's history, so webhooks and event streams see it like any update.
+//! \param tx Open transaction, e.g. of the project deletion.
+//! \param fromID ID of the project the tasks leave.
+//! \param toID ID of the project the tasks join.
+//! \param userID ID of the user making the change.
+//! \return Error (if any).
+func (s *Service) MoveProjectTasks(tx *sql.Tx, fromID, toID, userID int) error {
+	rows, err := tx.Query(`SELECT id FROM tasks WHERE project_id = $1 ORDER BY id FOR UPDATE`, fromID)
+	if err != nil {
+		s.logger.Error("Failed to lock project tasks", zap.Error(err))
+		return err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	changes := map[string]models.FieldChange{"project_id": {From: fromID, To: toID}}
+	for _, id := range ids {
+		query := `UPDATE tasks SET project_id = $1, version = version + 1 WHERE id = $2`
+		if _, err := tx.Exec(query, toID, id); err != nil {
+			s.logger.Error("Failed to move task", zap.Int("task_id", id), zap.Error(err))
+			return err
+		}
+		if err := s.recordEvent(tx, id, userID, ActionUpdated, changes); err != nil {
+			s.logger.Error("Failed to record task event", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package tasks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-tracker/internal/models"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+//! \var ErrInvalidCursor
+//! \brief Returned when a pagination cursor cannot be decoded or does not match the sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+//! \var ErrInvalidSort
+//! \brief Returned when an unsupported sort field or order is requested.
+var ErrInvalidSort = errors.New("invalid sort")
+
+//! \var sortColumns
+//! \brief Whitelist of sortable fields mapped to their SQL expressions.
+var sortColumns = map[string]string{
+	"due_date":   "due_date",
+	"priority":   "priority",
+	"created_at": "created_at",
+}
+
+//! \struct ListOptions
+//! \brief Filtering, sorting and pagination options for listing tasks.
+type ListOptions struct {
+	Statuses        []string
+	PriorityMin     *int
+	PriorityMax     *int
+	DueAfter        *time.Time
+	DueBefore       *time.Time
+	CreatedAfter    *time.Time
+	CreatedBefore   *time.Time
+	Labels          []string
+	LabelMatch      string
+	ProjectID       *int
+	IncludeArchived bool
+	Sort            string
+	Order           string
+	Limit           int
+	Cursor          string
+}
+
+//! \struct cursor
+//! \brief Keyset position encoded into an opaque pagination token.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+//! \fn normalize() error
+//! \brief Applies defaults and validates sort options.
+//! \return Error (if any).
+func (o *ListOptions) normalize() error {
+	if o.Sort == "" {
+		o.Sort = "created_at"
+	}
+	if o.Order == "" {
+		o.Order = "desc"
+	}
+	if _, ok := sortColumns[o.Sort]; !ok {
+		return ErrInvalidSort
+	}
+	if o.Order != "asc" && o.Order != "desc" {
+		return ErrInvalidSort
+	}
+	if o.LabelMatch == "" {
+		o.LabelMatch = LabelMatchAny
+	}
+	if o.LabelMatch != LabelMatchAny && o.LabelMatch != LabelMatchAll {
+		return ErrInvalidLabelMatch
+	}
+	if o.Limit <= 0 {
+		o.Limit = defaultListLimit
+	}
+	if o.Limit > maxListLimit {
+		o.Limit = maxListLimit
+	}
+	return nil
+}
+
+//! \fn sortKey() string
+//! \brief Identifies the sort order a cursor was issued for.
+//! \return Sort key such as "priority:desc".
+func (o *ListOptions) sortKey() string {
+	return o.Sort + ":" + o.Order
+}
+
+//! \fn encodeCursor(c cursor) string
+//! \brief Serializes a cursor into an opaque URL-safe token.
+//! \param c Cursor to encode.
+//! \return Encoded token.
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+//! \fn decodeCursor(token string) (cursor, error)
+//! \brief Parses an opaque pagination token.
+//! \param token Encoded token.
+//! \return Decoded cursor and error (if any).
+func decodeCursor(token string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+//! \fn cursorValue(sort string, value string) (interface{}, error)
+//! \brief Converts a cursor value back into the type of the sort column.
+//! \param sort Sort field.
+//! \param value Encoded value; empty for a task without a due date.
+//! \return Typed value (nil for no due date) and error (if any).
+func cursorValue(sort, value string) (interface{}, error) {
+	if sort == "due_date" && value == "" {
+		return nil, nil
+	}
+	if sort == "priority" {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return v, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return t, nil
+}
+
+//! \struct queryBuilder
+//! \brief Accumulates WHERE conditions and positional arguments.
+type queryBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+//! \fn arg(v interface{}) string
+//! \brief Registers an argument and returns its placeholder.
+//! \param v Argument value.
+//! \return Positional placeholder such as "$3".
+func (b *queryBuilder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+//! \fn where(format string, values ...interface{})
+//! \brief Adds a condition whose %s verbs are replaced by placeholders for values.
+//! \param format Condition template.
+//! \param values Condition arguments.
+func (b *queryBuilder) where(format string, values ...interface{}) {
+	placeholders := make([]interface{}, len(values))
+	for i, v := range values {
+		placeholders[i] = b.arg(v)
+	}
+	b.conds = append(b.conds, fmt.Sprintf(format, placeholders...))
+}
+
+//! \fn clause() string
+//! \brief Joins the accumulated conditions.
+//! \return Conditions joined with AND.
+func (b *queryBuilder) clause() string {
+	return strings.Join(b.conds, " AND ")
+}
+
+//! \fn buildListQuery(userID int, opts ListOptions) (string, []interface{}, error)
+//! \brief Builds the SQL query for a filtered, sorted page of tasks.
+//! \param userID ID of the user.
+//! \param opts Normalized list options.
+//! \return Query, arguments and error (if any).
+func buildListQuery(userID int, opts ListOptions) (string, []interface{}, error) {
+	b := &queryBuilder{}
+	filterConditions(b, userID, opts)
+
+	column := sortColumns[opts.Sort]
+	cmp, dir := ">", "ASC"
+	if opts.Order == "desc" {
+		cmp, dir = "<", "DESC"
+	}
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		if c.Sort != opts.sortKey() {
+			return "", nil, ErrInvalidCursor
+		}
+		value, err := cursorValue(opts.Sort, c.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		switch {
+		case opts.Sort != "due_date":
+			b.where("("+column+", id) "+cmp+" (%s, %s)", value, c.ID)
+		// Tasks without a due date come first in ascending order and last in descending order.
+		case value == nil && opts.Order == "asc":
+			b.where("(due_date IS NOT NULL OR id > %s)", c.ID)
+		case value == nil:
+			b.where("due_date IS NULL AND id < %s", c.ID)
+		case opts.Order == "asc":
+			b.where("(due_date, id) > (%s, %s)", value, c.ID)
+		default:
+			b.where("((due_date, id) < (%s, %s) OR due_date IS NULL)", value, c.ID)
+		}
+	}
+
+	nulls := ""
+	if opts.Sort == "due_date" {
+		nulls = " NULLS LAST"
+		if opts.Order == "asc" {
+			nulls = " NULLS FIRST"
+		}
+	}
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE ` + b.clause() +
+		` ORDER BY ` + column + ` ` + dir + nulls + `, id ` + dir +
+		` LIMIT ` + b.arg(opts.Limit+1)
+	return query, b.args, nil
+}
+
+//! \fn filterConditions(b *queryBuilder, userID int, opts ListOptions)
+//! \brief Adds the conditions selecting a user's live tasks that match the list filters.
+//! \param b Query builder receiving the conditions.
+//! \param userID ID of the user.
+//! \param opts Normalized list options.
+func filterConditions(b *queryBuilder, userID int, opts ListOptions) {
+	b.where("user_id = %s", userID)
+	b.conds = append(b.conds, "deleted_at IS NULL")
+
+	if len(opts.Statuses) > 0 {
+		placeholders := make([]string, len(opts.Statuses))
+		for i, status := range opts.Statuses {
+			placeholders[i] = b.arg(status)
+		}
+		b.conds = append(b.conds, "status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if opts.PriorityMin != nil {
+		b.where("priority >= %s", *opts.PriorityMin)
+	}
+	if opts.PriorityMax != nil {
+		b.where("priority <= %s", *opts.PriorityMax)
+	}
+	if opts.DueAfter != nil {
+		b.where("due_date >= %s", *opts.DueAfter)
+	}
+	if opts.DueBefore != nil {
+		b.where("due_date < %s", *opts.DueBefore)
+	}
+	if opts.CreatedAfter != nil {
+		b.where("created_at >= %s", *opts.CreatedAfter)
+	}
+	if opts.CreatedBefore != nil {
+		b.where("created_at < %s", *opts.CreatedBefore)
+	}
+	if opts.ProjectID != nil {
+		b.where("project_id = %s", *opts.ProjectID)
+	} else if !opts.IncludeArchived {
+		b.conds = append(b.conds, `NOT EXISTS (SELECT 1 FROM projects p
+                                               WHERE p.id = tasks.project_id AND p.archived_at IS NOT NULL)`)
+	}
+	if len(opts.Labels) > 0 {
+		matches := `SELECT COUNT(DISTINCT l.name) FROM task_labels tl JOIN labels l ON l.id = tl.label_id
+                    WHERE tl.task_id = tasks.id AND l.name = ANY(%s)`
+		if opts.LabelMatch == LabelMatchAll {
+			b.where("("+matches+") = %s", pq.Array(opts.Labels), len(uniqueStrings(opts.Labels)))
+		} else {
+			b.where("("+matches+") > 0", pq.Array(opts.Labels))
+		}
+	}
+}
+
+//! \fn cursorFor(task models.Task, opts ListOptions) cursor
+//! \brief Builds the cursor pointing just after the given task.
+//! \param task Last task of the current page.
+//! \param opts Normalized list options.
+//! \return Cursor for the next page.
+func cursorFor(task models.Task, opts ListOptions) cursor {
+	c := cursor{Sort: opts.sortKey(), ID: task.ID}
+	switch opts.Sort {
+	case "priority":
+		c.Value = strconv.Itoa(task.Priority)
+	case "due_date":
+		if task.DueDate != nil {
+			c.Value = task.DueDate.Format(time.RFC3339Nano)
+		}
+	default:
+		c.Value = task.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+//! \var validStatuses
+//! \brief Task statuses accepted by filters.
+var validStatuses = map[string]bool{
+	"pending":     true,
+	"in_progress": true,
+	"done":        true,
+}
+
+//! \fn uniqueStrings(values []string) []string
+//! \brief Removes duplicate values, keeping the first occurrence.
+//! \param values Input values.
+//! \return Distinct values.
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"task-tracker/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	due := time.Date(2026, 3, 10, 9, 30, 0, 123456789, time.UTC)
+	created := time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC)
+	tests := []struct {
+		name string
+		task models.Task
+		opts ListOptions
+		want cursor
+	}{
+		{"created_at", models.Task{ID: 7, CreatedAt: created}, ListOptions{Sort: "created_at", Order: "desc"},
+			cursor{Sort: "created_at:desc", Value: "2025-12-31T23:59:59Z", ID: 7}},
+		{"priority", models.Task{ID: 3, Priority: 5}, ListOptions{Sort: "priority", Order: "asc"},
+			cursor{Sort: "priority:asc", Value: "5", ID: 3}},
+		{"due_date", models.Task{ID: 9, DueDate: &due}, ListOptions{Sort: "due_date", Order: "asc"},
+			cursor{Sort: "due_date:asc", Value: "2026-03-10T09:30:00.123456789Z", ID: 9}},
+		{"no due_date", models.Task{ID: 4}, ListOptions{Sort: "due_date", Order: "desc"},
+			cursor{Sort: "due_date:desc", Value: "", ID: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cursorFor(tt.task, tt.opts)
+			if c != tt.want {
+				t.Fatalf("cursorFor() = %+v, want %+v", c, tt.want)
+			}
+			token := encodeCursor(c)
+			if strings.ContainsAny(token, "+/=") {
+				t.Errorf("encodeCursor() = %q, not URL-safe", token)
+			}
+			decoded, err := decodeCursor(token)
+			if err != nil {
+				t.Fatalf("decodeCursor() error = %v", err)
+			}
+			if decoded != c {
+				t.Errorf("decodeCursor() = %+v, want %+v", decoded, c)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"not base64", "!!!"},
+		{"not json", "bm90IGpzb24"},
+		{"padded", "eyJzIjoicHJpb3JpdHk6YXNjIn0="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.token); err != ErrInvalidCursor {
+				t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidCursor", tt.token, err)
+			}
+		})
+	}
+}
+
+func TestCursorValue(t *testing.T) {
+	tests := []struct {
+		sort    string
+		value   string
+		want    interface{}
+		wantErr bool
+	}{
+		{"priority", "3", 3, false},
+		{"priority", "high", nil, true},
+		{"created_at", "2026-01-02T03:04:05Z", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), false},
+		{"created_at", "", nil, true},
+		{"due_date", "2026-01-02T03:04:05Z", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), false},
+		{"due_date", "", nil, false},
+		{"due_date", "yesterday", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort+"/"+tt.value, func(t *testing.T) {
+			got, err := cursorValue(tt.sort, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cursorValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tm, ok := tt.want.(time.Time); ok {
+				if gt, ok := got.(time.Time); !ok || !gt.Equal(tm) {
+					t.Errorf("cursorValue() = %v, want %v", got, tt.want)
+				}
+			} else if got != tt.want {
+				t.Errorf("cursorValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildListQueryKeyset(t *testing.T) {
+	due := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		opts      ListOptions
+		after     models.Task
+		wantWhere string
+		wantOrder string
+	}{
+		{"created_at desc", ListOptions{Sort: "created_at", Order: "desc"}, models.Task{ID: 5},
+			"(created_at, id) < ($2, $3)", "ORDER BY created_at DESC, id DESC"},
+		{"due_date asc", ListOptions{Sort: "due_date", Order: "asc"}, models.Task{ID: 5, DueDate: &due},
+			"(due_date, id) > ($2, $3)", "ORDER BY due_date ASC NULLS FIRST, id ASC"},
+		{"due_date asc after undated", ListOptions{Sort: "due_date", Order: "asc"}, models.Task{ID: 5},
+			"(due_date IS NOT NULL OR id > $2)", "ORDER BY due_date ASC NULLS FIRST, id ASC"},
+		{"due_date desc", ListOptions{Sort: "due_date", Order: "desc"}, models.Task{ID: 5, DueDate: &due},
+			"((due_date, id) < ($2, $3) OR due_date IS NULL)", "ORDER BY due_date DESC NULLS LAST, id DESC"},
+		{"due_date desc after undated", ListOptions{Sort: "due_date", Order: "desc"}, models.Task{ID: 5},
+			"due_date IS NULL AND id < $2", "ORDER BY due_date DESC NULLS LAST, id DESC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.IncludeArchived = true
+			if err := opts.normalize(); err != nil {
+				t.Fatalf("normalize() error = %v", err)
+			}
+			opts.Cursor = encodeCursor(cursorFor(tt.after, opts))
+			query, _, err := buildListQuery(1, opts)
+			if err != nil {
+				t.Fatalf("buildListQuery() error = %v", err)
+			}
+			if !strings.Contains(query, tt.wantWhere) {
+				t.Errorf("query %q does not contain %q", query, tt.wantWhere)
+			}
+			if !strings.Contains(query, tt.wantOrder) {
+				t.Errorf("query %q does not contain %q", query, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestBuildListQueryCursorMismatch(t *testing.T) {
+	opts := ListOptions{Sort: "priority", Order: "asc"}
+	if err := opts.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	opts.Cursor = encodeCursor(cursor{Sort: "priority:desc", Value: "3", ID: 1})
+	if _, _, err := buildListQuery(1, opts); err != ErrInvalidCursor {
+		t.Errorf("buildListQuery() error = %v, want ErrInvalidCursor", err)
+	}
+}
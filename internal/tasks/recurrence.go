@@ -0,0 +1,165 @@
+package tasks
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"task-tracker/internal/models"
+	"task-tracker/internal/reminders"
+	"task-tracker/internal/rrule"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPreviewCount = 5
+	maxPreviewCount     = 100
+)
+
+//! \var ErrRecurrenceNeedsDueDate
+//! \brief Returned when a recurring task has no due date to anchor its series.
+var ErrRecurrenceNeedsDueDate = errors.New("recurring task requires a due_date")
+
+//! \fn normalizeRecurrence(task *models.Task) (*rrule.Rule, error)
+//! \brief Validates a task's recurrence rule and rewrites it in canonical form.
+//! \param task Task to check; an empty rule is cleared.
+//! \return Parsed rule (nil if the task does not recur) and error (if any).
+func normalizeRecurrence(task *models.Task) (*rrule.Rule, error) {
+	if task.Recurrence == nil || *task.Recurrence == "" {
+		task.Recurrence = nil
+		return nil, nil
+	}
+	rule, err := rrule.Parse(*task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	if task.DueDate == nil {
+		return nil, ErrRecurrenceNeedsDueDate
+	}
+	canonical := rule.String()
+	task.Recurrence = &canonical
+	return rule, nil
+}
+
+//! \fn nextOccurrence(task *models.Task, rule *rrule.Rule) *models.Task
+//! \brief Builds the task for the occurrence following a completed one.
+//! \param task Completed occurrence.
+//! \param rule Its recurrence rule.
+//! \return Next occurrence, or nil when the series has ended.
+func nextOccurrence(task *models.Task, rule *rrule.Rule) *models.Task {
+	dates := rule.Next(*task.DueDate, 1)
+	rest := rule.Advance()
+	if len(dates) == 0 || rest == nil {
+		return nil
+	}
+	recurrence := rest.String()
+	return &models.Task{
+		UserID:      task.UserID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      "pending",
+		Priority:    task.Priority,
+		DueDate:     &dates[0],
+		ParentID:    task.ParentID,
+		ProjectID:   task.ProjectID,
+		Recurrence:  &recurrence,
+	}
+}
+
+//! \fn spawnOccurrence(tx *sql.Tx, completed, next *models.Task, userID int) error
+//! \brief Creates the next occurrence of a recurring task, carrying over its labels and relative reminders.
+//! \param tx Open transaction completing the previous occurrence.
+//! \param completed Completed occurrence.
+//! \param next Next occurrence; receives its ID.
+//! \param userID ID of the user completing the task.
+//! \return Error (if any).
+func (s *Service) spawnOccurrence(tx *sql.Tx, completed, next *models.Task, userID int) error {
+	id, err := s.insertTask(tx, next, userID)
+	if err != nil {
+		return err
+	}
+	next.ID = id
+
+	query := `INSERT INTO task_labels (task_id, label_id) SELECT $1, label_id FROM task_labels WHERE task_id = $2`
+	if _, err := tx.Exec(query, id, completed.ID); err != nil {
+		s.logger.Error("Failed to copy task labels", zap.Error(err))
+		return err
+	}
+	if err := reminders.CopyRelative(tx, completed.ID, id, *next.DueDate); err != nil {
+		s.logger.Error("Failed to copy task reminders", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Next occurrence created", zap.Int("task_id", id), zap.Int("previous_id", completed.ID),
+		zap.Time("due_date", *next.DueDate))
+	return nil
+}
+
+//! \fn GetOccurrences(taskID string, userID int, n int) ([]time.Time, error)
+//! \brief Previews the upcoming due dates of a recurring task.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \param n Number of occurrences to list.
+//! \return Due dates after the task's own, empty if it does not recur, and error (if any).
+func (s *Service) GetOccurrences(taskID string, userID int, n int) ([]time.Time, error) {
+	task, err := s.GetTask(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		n = defaultPreviewCount
+	}
+	if n > maxPreviewCount {
+		n = maxPreviewCount
+	}
+
+	occurrences := []time.Time{}
+	if task.Recurrence == nil || task.DueDate == nil {
+		return occurrences, nil
+	}
+	rule, err := rrule.Parse(*task.Recurrence)
+	if err != nil {
+		s.logger.Error("Stored recurrence rule is invalid", zap.String("task_id", taskID), zap.Error(err))
+		return nil, err
+	}
+	return append(occurrences, rule.Next(*task.DueDate, n)...), nil
+}
+
+//! \fn StopRecurrence(taskID string, userID int, cond Precondition) (*models.Task, error)
+//! \brief Removes a task's recurrence rule so completing it no longer spawns a successor.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \param cond Versions the client expects the task to be at.
+//! \return Updated task and error (if any).
+func (s *Service) StopRecurrence(taskID string, userID int, cond Precondition) (*models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := lockTask(tx, taskID, userID)
+	if err != nil {
+		s.logger.Warn("Task not found", zap.String("task_id", taskID), zap.Error(err))
+		return nil, err
+	}
+	if !cond.allows(current.Version) {
+		s.logger.Warn("Task version mismatch", zap.String("task_id", taskID), zap.Int("version", current.Version))
+		return nil, ErrPreconditionFailed
+	}
+
+	next := *current
+	next.Recurrence = nil
+	if err := s.applyUpdate(tx, current, &next, userID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Task recurrence stopped", zap.String("task_id", taskID), zap.Int("user_id", userID))
+	return &next, nil
+}
@@ -0,0 +1,105 @@
+package tasks
+
+import (
+	"errors"
+	"html"
+	"strings"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+//! \brief Private-use characters delimiting matches in ts_headline output until it is escaped.
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+//! \brief ts_headline options for titles and descriptions.
+var (
+	titleHighlight       = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", HighlightAll=true`
+	descriptionHighlight = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxFragments=2, MaxWords=20, MinWords=5`
+)
+
+//! \var highlightMarks
+//! \brief Turns the match delimiters into mark elements.
+var highlightMarks = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+//! \fn markHighlights(snippet string) string
+//! \brief HTML-escapes a ts_headline snippet and wraps its matches in <mark></mark>, so that
+//!        clients can render it as HTML without user text being interpreted as markup.
+//! \param snippet Snippet delimited with highlightStart and highlightStop.
+//! \return Safe HTML snippet.
+func markHighlights(snippet string) string {
+	return highlightMarks.Replace(html.EscapeString(snippet))
+}
+
+//! \var ErrEmptyQuery
+//! \brief Returned when a search is requested without a query.
+var ErrEmptyQuery = errors.New("search query is required")
+
+//! \struct SearchResult
+//! \brief A task matching a full-text search with its rank and highlighted snippets.
+type SearchResult struct {
+	models.Task
+	Rank               float64 `json:"rank"`
+	TitleSnippet       string  `json:"title_snippet"`
+	DescriptionSnippet string  `json:"description_snippet"`
+}
+
+//! \fn SearchTasks(userID int, q string, limit int) ([]SearchResult, error)
+//! \brief Searches a user's task titles and descriptions.
+//! \param userID ID of the user.
+//! \param q Search query in web search syntax (quoted phrases, OR, -exclusion).
+//! \param limit Maximum number of results.
+//! \return Results ordered by rank and error (if any).
+func (s *Service) SearchTasks(userID int, q string, limit int) ([]SearchResult, error) {
+	if q == "" {
+		return nil, ErrEmptyQuery
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	query := `SELECT ` + taskColumns + `,
+                     ts_rank(search_vector, q) AS rank,
+                     ts_headline('english', title, q, $4),
+                     ts_headline('english', coalesce(description, ''), q, $5)
+              FROM tasks, websearch_to_tsquery('english', $2) AS q
+              WHERE user_id = $1 AND deleted_at IS NULL AND search_vector @@ q
+              ORDER BY rank DESC, id DESC
+              LIMIT $3`
+	rows, err := s.db.Query(query, userID, q, limit, titleHighlight, descriptionHighlight)
+	if err != nil {
+		s.logger.Error("Failed to search tasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var r SearchResult
+		if err := scanTask(rows, &r.Task, &r.Rank, &r.TitleSnippet, &r.DescriptionSnippet); err != nil {
+			s.logger.Error("Failed to scan search result", zap.Error(err))
+			return nil, err
+		}
+		r.TitleSnippet = markHighlights(r.TitleSnippet)
+		r.DescriptionSnippet = markHighlights(r.DescriptionSnippet)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate search results", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Tasks searched", zap.Int("user_id", userID), zap.Int("count", len(results)))
+	return results, nil
+}
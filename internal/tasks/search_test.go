@@ -0,0 +1,26 @@
+package tasks
+
+import "testing"
+
+func TestMarkHighlights(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{"plain", "buy milk", "buy milk"},
+		{"match", "buy " + highlightStart + "milk" + highlightStop, "buy <mark>milk</mark>"},
+		{"markup", `<img src=x onerror="alert(1)"> ` + highlightStart + "milk" + highlightStop,
+			"&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>milk</mark>"},
+		{"literal mark", "<mark>not a match</mark>", "&lt;mark&gt;not a match&lt;/mark&gt;"},
+		{"ampersand", "salt & " + highlightStart + "pepper" + highlightStop, "salt &amp; <mark>pepper</mark>"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markHighlights(tt.snippet); got != tt.want {
+				t.Errorf("markHighlights(%q) = %q, want %q", tt.snippet, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,143 +2,454 @@ package tasks
 
 import (
 	"database/sql"
+	"time"
 
+	"task-tracker/internal/events"
 	"task-tracker/internal/models"
+	"task-tracker/internal/projects"
+	"task-tracker/internal/reminders"
 	"go.uber.org/zap"
 )
 
+//! \const taskColumns
+//! \brief Column list matching the scan order of scanTask.
+const taskColumns = `id, user_id, title, description, status, priority, due_date, created_at, version, deleted_at, parent_id, project_id, recurrence,
+                       (SELECT (100 * COUNT(*) FILTER (WHERE c.status = 'done') / NULLIF(COUNT(*), 0))::int
+                        FROM tasks c WHERE c.parent_id = tasks.id AND c.deleted_at IS NULL) AS progress`
+
 //! \struct Service
 //! \brief Handles task-related business logic.
 type Service struct {
-	db     *sql.DB
-	logger *zap.Logger
+	db             *sql.DB
+	bus            events.Bus
+	requireIfMatch bool
+	logger         *zap.Logger
 }
 
-//! \fn NewService(db *sql.DB, logger *zap.Logger) *Service
+//! \fn NewService(db *sql.DB, bus events.Bus, requireIfMatch bool, logger *zap.Logger) *Service
 //! \brief Initializes a new task service.
 //! \param db Database connection.
+//! \param bus Event bus receiving task changes.
+//! \param requireIfMatch Whether mutations must carry an If-Match header.
 //! \param logger Logger instance.
 //! \return Pointer to initialized Service.
-func NewService(db *sql.DB, logger *zap.Logger) *Service {
+func NewService(db *sql.DB, bus events.Bus, requireIfMatch bool, logger *zap.Logger) *Service {
 	return &Service{
-		db:     db,
-		logger: logger,
+		db:             db,
+		bus:            bus,
+		requireIfMatch: requireIfMatch,
+		logger:         logger,
+	}
+}
+
+//! \interface rowScanner
+//! \brief Common interface of *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//! \fn scanTask(row rowScanner, task *models.Task, extra ...interface{}) error
+//! \brief Scans the taskColumns of a row into a task, followed by any extra columns.
+//! \param row Row to scan.
+//! \param task Destination task.
+//! \param extra Destinations of columns selected after taskColumns.
+//! \return Error (if any).
+func scanTask(row rowScanner, task *models.Task, extra ...interface{}) error {
+	dest := []interface{}{&task.ID, &task.UserID, &task.Title, &task.Description,
+		&task.Status, &task.Priority, &task.DueDate, &task.CreatedAt, &task.Version, &task.DeletedAt,
+		&task.ParentID, &task.ProjectID, &task.Recurrence, &task.Progress}
+	return row.Scan(append(dest, extra...)...)
+}
+
+//! \fn utcTime(t *time.Time) *time.Time
+//! \brief Converts an optional instant to UTC; due_date has no time zone, so instants are stored in UTC.
+//! \param t Instant, or nil if unset.
+//! \return Instant in UTC, or nil.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+//! \fn lockTask(tx *sql.Tx, taskID string, userID int) (*models.Task, error)
+//! \brief Loads a user's task that is not in the trash inside a transaction and locks its row.
+//! \param tx Open transaction.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \return Current task and error (if any).
+func lockTask(tx *sql.Tx, taskID string, userID int) (*models.Task, error) {
+	var task models.Task
+	query := `SELECT ` + taskColumns + ` FROM tasks
+              WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE`
+	if err := scanTask(tx.QueryRow(query, taskID, userID), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+//! \fn applyUpdate(tx *sql.Tx, current, next *models.Task, userID int) error
+//! \brief Writes a locked task's new state and records the change in its history.
+//! \param tx Open transaction holding the task's row lock.
+//! \param current Task as currently stored.
+//! \param next New task state; receives the new version.
+//! \param userID ID of the user making the change.
+//! \return Error (if any).
+func (s *Service) applyUpdate(tx *sql.Tx, current, next *models.Task, userID int) error {
+	if err := checkParent(tx, current.ID, next.ParentID, current.UserID); err != nil {
+		s.logger.Warn("Invalid parent task", zap.Int("task_id", current.ID), zap.Error(err))
+		return err
+	}
+	if next.ProjectID == 0 {
+		next.ProjectID = current.ProjectID
+	} else if next.ProjectID != current.ProjectID {
+		if err := checkProject(tx, next.ProjectID, current.UserID); err != nil {
+			s.logger.Warn("Invalid project", zap.Int("task_id", current.ID), zap.Error(err))
+			return err
+		}
+	}
+	rule, err := normalizeRecurrence(next)
+	if err != nil {
+		s.logger.Warn("Invalid recurrence", zap.Int("task_id", current.ID), zap.Error(err))
+		return err
+	}
+	var successor *models.Task
+	if next.Status == "done" && current.Status != "done" {
+		if err := checkUnblocked(tx, current.ID); err != nil {
+			s.logger.Warn("Task is blocked", zap.Int("task_id", current.ID), zap.Error(err))
+			return err
+		}
+		// The rule moves on to the next occurrence, so reopening this one cannot spawn it twice.
+		if rule != nil {
+			successor = nextOccurrence(next, rule)
+			next.Recurrence = nil
+		}
+	}
+
+	next.DueDate = utcTime(next.DueDate)
+	query := `UPDATE tasks SET title = $1, description = $2, status = $3, priority = $4, due_date = $5,
+              parent_id = $6, project_id = $7, recurrence = $8, version = version + 1
+              WHERE id = $9 RETURNING version`
+	err = tx.QueryRow(query, next.Title, next.Description, next.Status, next.Priority,
+		next.DueDate, next.ParentID, next.ProjectID, next.Recurrence, current.ID).Scan(&next.Version)
+	if err != nil {
+		s.logger.Error("Failed to update task", zap.Error(err))
+		return err
+	}
+
+	if changes := diffTasks(current, next); len(changes) > 0 {
+		if err := s.recordEvent(tx, current.ID, userID, ActionUpdated, changes); err != nil {
+			s.logger.Error("Failed to record task event", zap.Error(err))
+			return err
+		}
 	}
+	if !equalTimes(current.DueDate, next.DueDate) {
+		if err := reminders.Reschedule(tx, current.ID, next.DueDate); err != nil {
+			s.logger.Error("Failed to reschedule reminders", zap.Error(err))
+			return err
+		}
+	}
+	if successor != nil {
+		return s.spawnOccurrence(tx, next, successor, userID)
+	}
+	return nil
 }
 
-//! \fn GetTasks(userID int) ([]models.Task, error)
-//! \brief Retrieves tasks for a user.
+//! \fn trashTask(tx *sql.Tx, task *models.Task, userID int) error
+//! \brief Moves a locked task to the trash and records the deletion in its history.
+//! \param tx Open transaction holding the task's row lock.
+//! \param task Task to trash.
+//! \param userID ID of the user making the change.
+//! \return Error (if any).
+func (s *Service) trashTask(tx *sql.Tx, task *models.Task, userID int) error {
+	query := `UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $1`
+	if _, err := tx.Exec(query, task.ID); err != nil {
+		s.logger.Error("Failed to delete task", zap.Error(err))
+		return err
+	}
+
+	if err := s.recordEvent(tx, task.ID, userID, ActionDeleted, nil); err != nil {
+		s.logger.Error("Failed to record task event", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+//! \fn GetTasks(userID int, opts ListOptions) ([]models.Task, string, error)
+//! \brief Retrieves a filtered, sorted page of tasks for a user.
 //! \param userID ID of the user.
-//! \return List of tasks and error (if any).
-func (s *Service) GetTasks(userID int) ([]models.Task, error) {
-	query := `SELECT id, user_id, title, description, status, priority, due_date, created_at 
-              FROM tasks WHERE user_id = $1`
-	rows, err := s.db.Query(query, userID)
+//! \param opts Filtering, sorting and pagination options.
+//! \return List of tasks, cursor of the next page (empty on the last page) and error (if any).
+func (s *Service) GetTasks(userID int, opts ListOptions) ([]models.Task, string, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, "", err
+	}
+	query, args, err := buildListQuery(userID, opts)
+	if err != nil {
+		return nil, "", err
+	}
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		s.logger.Error("Failed to fetch tasks", zap.Error(err))
-		return nil, err
+		return nil, "", err
 	}
 	defer rows.Close()
 
-	var tasks []models.Task
+	tasks := []models.Task{}
 	for rows.Next() {
 		var task models.Task
-		if err := rows.Scan(&task.ID, &task.UserID, &task.Title, &task.Description, 
-			&task.Status, &task.Priority, &task.DueDate, &task.CreatedAt); err != nil {
+		if err := scanTask(rows, &task); err != nil {
 			s.logger.Error("Failed to scan task", zap.Error(err))
-			continue
+			return nil, "", err
 		}
 		tasks = append(tasks, task)
 	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate tasks", zap.Error(err))
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(tasks) > opts.Limit {
+		tasks = tasks[:opts.Limit]
+		nextCursor = encodeCursor(cursorFor(tasks[len(tasks)-1], opts))
+	}
+
+	if err := s.loadLabels(tasks); err != nil {
+		s.logger.Error("Failed to fetch task labels", zap.Error(err))
+		return nil, "", err
+	}
 
 	s.logger.Info("Tasks retrieved", zap.Int("user_id", userID), zap.Int("count", len(tasks)))
-	return tasks, nil
+	return tasks, nextCursor, nil
+}
+
+//! \fn GetProjectTasks(projectID string, userID int, opts ListOptions) ([]models.Task, string, error)
+//! \brief Retrieves a filtered, sorted page of the tasks in one of the user's projects.
+//! \param projectID ID of the project.
+//! \param userID ID of the user.
+//! \param opts Filtering, sorting and pagination options; ProjectID is overridden.
+//! \return List of tasks, cursor of the next page and error (if any).
+func (s *Service) GetProjectTasks(projectID string, userID int, opts ListOptions) ([]models.Task, string, error) {
+	var id int
+	query := `SELECT id FROM projects WHERE id = $1 AND user_id = $2`
+	if err := s.db.QueryRow(query, projectID, userID).Scan(&id); err != nil {
+		s.logger.Warn("Project not found", zap.String("project_id", projectID), zap.Error(err))
+		return nil, "", err
+	}
+	opts.ProjectID = &id
+	return s.GetTasks(userID, opts)
 }
 
 //! \fn CreateTask(task *models.Task) (int, error)
-//! \brief Creates a new task in the database.
+//! \brief Creates a new task in the database and records it in the task history.
 //! \param task Task data to create.
 //! \return Task ID and error (if any).
 func (s *Service) CreateTask(task *models.Task) (int, error) {
-	query := `INSERT INTO tasks (user_id, title, description, status, priority, due_date) 
-              VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	taskID, err := s.createTask(tx, task)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return 0, err
+	}
+
+	s.logger.Info("Task created", zap.Int("task_id", taskID), zap.Int("user_id", task.UserID))
+	return taskID, nil
+}
+
+//! \fn createTask(tx *sql.Tx, task *models.Task) (int, error)
+//! \brief Checks a new task's parent, project and recurrence and inserts it.
+//! \param tx Open transaction.
+//! \param task Task data to create; tasks without a project go to the user's Inbox.
+//! \return Task ID and error (if any).
+func (s *Service) createTask(tx *sql.Tx, task *models.Task) (int, error) {
+	var err error
+	if err := checkParent(tx, 0, task.ParentID, task.UserID); err != nil {
+		s.logger.Warn("Invalid parent task", zap.Error(err))
+		return 0, err
+	}
+	if task.ProjectID == 0 {
+		if task.ProjectID, err = projects.EnsureInbox(tx, task.UserID); err != nil {
+			s.logger.Error("Failed to ensure Inbox project", zap.Error(err))
+			return 0, err
+		}
+	} else if err := checkProject(tx, task.ProjectID, task.UserID); err != nil {
+		s.logger.Warn("Invalid project", zap.Error(err))
+		return 0, err
+	}
+	if _, err := normalizeRecurrence(task); err != nil {
+		s.logger.Warn("Invalid recurrence", zap.Error(err))
+		return 0, err
+	}
+	return s.insertTask(tx, task, task.UserID)
+}
+
+//! \fn insertTask(tx *sql.Tx, task *models.Task, actorID int) (int, error)
+//! \brief Inserts a validated task and records its creation in the task history.
+//! \param tx Open transaction.
+//! \param task Task to insert.
+//! \param actorID ID of the user creating the task.
+//! \return Task ID and error (if any).
+func (s *Service) insertTask(tx *sql.Tx, task *models.Task, actorID int) (int, error) {
+	task.DueDate = utcTime(task.DueDate)
+	query := `INSERT INTO tasks (user_id, title, description, status, priority, due_date, parent_id, project_id, recurrence)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
 	var taskID int
-	err := s.db.QueryRow(query, task.UserID, task.Title, task.Description, 
-		task.Status, task.Priority, task.DueDate).Scan(&taskID)
+	err := tx.QueryRow(query, task.UserID, task.Title, task.Description, task.Status, task.Priority,
+		task.DueDate, task.ParentID, task.ProjectID, task.Recurrence).Scan(&taskID)
 	if err != nil {
 		s.logger.Error("Failed to create task", zap.Error(err))
 		return 0, err
 	}
 
-	s.logger.Info("Task created", zap.Int("task_id", taskID), zap.Int("user_id", task.UserID))
+	if err := s.recordEvent(tx, taskID, actorID, ActionCreated, diffTasks(nil, task)); err != nil {
+		s.logger.Error("Failed to record task event", zap.Error(err))
+		return 0, err
+	}
 	return taskID, nil
 }
 
 //! \fn GetTask(taskID string, userID int) (*models.Task, error)
-//! \brief Retrieves a specific task by ID for a user.
+//! \brief Retrieves a specific task by ID for a user, including its blockers and blocked tasks.
 //! \param taskID ID of the task.
 //! \param userID ID of the user.
 //! \return Task and error (if any).
 func (s *Service) GetTask(taskID string, userID int) (*models.Task, error) {
 	var task models.Task
-	query := `SELECT id, user_id, title, description, status, priority, due_date, created_at 
-              FROM tasks WHERE id = $1 AND user_id = $2`
-	err := s.db.QueryRow(query, taskID, userID).Scan(&task.ID, &task.UserID, &task.Title, 
-		&task.Description, &task.Status, &task.Priority, &task.DueDate, &task.CreatedAt)
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	err := scanTask(s.db.QueryRow(query, taskID, userID), &task)
 	if err != nil {
 		s.logger.Warn("Task not found", zap.Error(err))
 		return nil, err
 	}
 
+	if err := s.loadBlocking(&task); err != nil {
+		s.logger.Error("Failed to fetch task links", zap.Error(err))
+		return nil, err
+	}
+	batch := []models.Task{task}
+	if err := s.loadLabels(batch); err != nil {
+		s.logger.Error("Failed to fetch task labels", zap.Error(err))
+		return nil, err
+	}
+	task = batch[0]
+
 	s.logger.Info("Task retrieved", zap.String("task_id", taskID), zap.Int("user_id", userID))
 	return &task, nil
 }
 
-//! \fn UpdateTask(task *models.Task, taskID string, userID int) error
-//! \brief Updates a task in the database.
-//! \param task Updated task data.
+//! \fn UpdateTask(task *models.Task, taskID string, userID int, cond Precondition, omitted Omitted) error
+//! \brief Updates a task in the database and bumps its version.
+//! \param task Updated task data; receives the new version.
 //! \param taskID ID of the task.
 //! \param userID ID of the user.
+//! \param cond Versions the client expects the task to be at.
+//! \param omitted Fields absent from the request, which keep their stored value.
 //! \return Error (if any).
-func (s *Service) UpdateTask(task *models.Task, taskID string, userID int) error {
-	query := `UPDATE tasks SET title = $1, description = $2, status = $3, priority = $4, due_date = $5 
-              WHERE id = $6 AND user_id = $7`
-	result, err := s.db.Exec(query, task.Title, task.Description, task.Status, task.Priority, 
-		task.DueDate, taskID, userID)
+func (s *Service) UpdateTask(task *models.Task, taskID string, userID int, cond Precondition, omitted Omitted) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		s.logger.Error("Failed to update task", zap.Error(err))
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.updateTask(tx, task, taskID, userID, cond, omitted); err != nil {
 		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		s.logger.Warn("Task not found", zap.String("task_id", taskID))
-		return sql.ErrNoRows
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
 	}
 
 	s.logger.Info("Task updated", zap.String("task_id", taskID), zap.Int("user_id", userID))
 	return nil
 }
 
-//! \fn DeleteTask(taskID string, userID int) error
-//! \brief Deletes a task from the database.
+//! \fn updateTask(tx *sql.Tx, task *models.Task, taskID string, userID int, cond Precondition, omitted Omitted) error
+//! \brief Locks a task, checks the precondition and replaces its state.
+//! \param tx Open transaction.
+//! \param task Updated task data; receives the new version.
 //! \param taskID ID of the task.
 //! \param userID ID of the user.
+//! \param cond Versions the client expects the task to be at.
+//! \param omitted Fields absent from the request, which keep their stored value.
 //! \return Error (if any).
-func (s *Service) DeleteTask(taskID string, userID int) error {
-	query := `DELETE FROM tasks WHERE id = $1 AND user_id = $2`
-	result, err := s.db.Exec(query, taskID, userID)
+func (s *Service) updateTask(tx *sql.Tx, task *models.Task, taskID string, userID int, cond Precondition, omitted Omitted) error {
+	current, err := lockTask(tx, taskID, userID)
 	if err != nil {
-		s.logger.Error("Failed to delete task", zap.Error(err))
+		s.logger.Warn("Task not found", zap.String("task_id", taskID), zap.Error(err))
+		return err
+	}
+	if !cond.allows(current.Version) {
+		s.logger.Warn("Task version mismatch", zap.String("task_id", taskID), zap.Int("version", current.Version))
+		return ErrPreconditionFailed
+	}
+	omitted.keep(current, task)
+	return s.applyUpdate(tx, current, task, userID)
+}
+
+//! \fn DeleteTask(taskID string, userID int, cond Precondition, policy DeletePolicy) error
+//! \brief Moves a task to the trash, handling its subtasks according to the policy.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \param cond Versions the client expects the task to be at.
+//! \param policy What happens to the task's subtasks.
+//! \return Error (if any).
+func (s *Service) DeleteTask(taskID string, userID int, cond Precondition, policy DeletePolicy) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.deleteTask(tx, taskID, userID, cond, policy); err != nil {
 		return err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		s.logger.Warn("Task not found", zap.String("task_id", taskID))
-		return sql.ErrNoRows
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return err
 	}
 
-	s.logger.Info("Task deleted", zap.String("task_id", taskID), zap.Int("user_id", userID))
+	s.logger.Info("Task moved to trash", zap.String("task_id", taskID), zap.Int("user_id", userID))
 	return nil
-}
\ No newline at end of file
+}
+
+//! \fn deleteTask(tx *sql.Tx, taskID string, userID int, cond Precondition, policy DeletePolicy) error
+//! \brief Locks a task, checks the precondition and moves it to the trash.
+//! \param tx Open transaction.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \param cond Versions the client expects the task to be at.
+//! \param policy What happens to the task's subtasks.
+//! \return Error (if any).
+func (s *Service) deleteTask(tx *sql.Tx, taskID string, userID int, cond Precondition, policy DeletePolicy) error {
+	current, err := lockTask(tx, taskID, userID)
+	if err != nil {
+		s.logger.Warn("Task not found", zap.String("task_id", taskID), zap.Error(err))
+		return err
+	}
+	if !cond.allows(current.Version) {
+		s.logger.Warn("Task version mismatch", zap.String("task_id", taskID), zap.Int("version", current.Version))
+		return ErrPreconditionFailed
+	}
+
+	if err := s.releaseChildren(tx, current, userID, policy); err != nil {
+		return err
+	}
+	return s.trashTask(tx, current, userID)
+}
@@ -0,0 +1,309 @@
+package tasks
+
+import (
+	"database/sql"
+	"errors"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \type DeletePolicy
+//! \brief Decides what happens to a task's subtasks when it is deleted.
+type DeletePolicy string
+
+//! \brief Supported delete policies.
+const (
+	DeleteReject  DeletePolicy = "reject"
+	DeleteCascade DeletePolicy = "cascade"
+	DeleteOrphan  DeletePolicy = "orphan"
+)
+
+//! \var ErrInvalidParent
+//! \brief Returned when a parent task does not exist or belongs to another user.
+var ErrInvalidParent = errors.New("parent task not found")
+
+//! \var ErrInvalidProject
+//! \brief Returned when a project does not exist, belongs to another user or is archived.
+var ErrInvalidProject = errors.New("project not found or archived")
+
+//! \var ErrParentCycle
+//! \brief Returned when a parent assignment would make a task its own ancestor.
+var ErrParentCycle = errors.New("parent assignment would create a cycle")
+
+//! \var ErrHasChildren
+//! \brief Returned when deleting a task with subtasks under the reject policy.
+var ErrHasChildren = errors.New("task has subtasks")
+
+//! \var ErrInvalidDeletePolicy
+//! \brief Returned when an unknown delete policy is requested.
+var ErrInvalidDeletePolicy = errors.New("invalid delete policy")
+
+//! \struct TaskNode
+//! \brief A task with its subtasks, forming a tree.
+type TaskNode struct {
+	models.Task
+	Children []*TaskNode `json:"children"`
+}
+
+//! \fn ParseDeletePolicy(value string) (DeletePolicy, error)
+//! \brief Parses a delete policy, defaulting to reject.
+//! \param value Policy name.
+//! \return Delete policy and error (if any).
+func ParseDeletePolicy(value string) (DeletePolicy, error) {
+	switch DeletePolicy(value) {
+	case "":
+		return DeleteReject, nil
+	case DeleteReject, DeleteCascade, DeleteOrphan:
+		return DeletePolicy(value), nil
+	}
+	return "", ErrInvalidDeletePolicy
+}
+
+//! \const parentLockSpace
+//! \brief First key of the advisory lock that serializes parent changes per user. The two-key form keeps
+//!        it apart from the link lock, which is taken before the task rows rather than after them.
+const parentLockSpace = 1
+
+//! \fn checkParent(tx *sql.Tx, taskID int, parentID *int, userID int) error
+//! \brief Verifies that a parent exists, belongs to the user and is not a descendant of the task.
+//!        Holds a per-user lock until the transaction ends, so concurrent moves cannot form a cycle.
+//! \param tx Open transaction.
+//! \param taskID ID of the task being parented; 0 for a new task.
+//! \param parentID Proposed parent ID; nil for a top-level task.
+//! \param userID ID of the task's owner.
+//! \return Error (if any).
+func checkParent(tx *sql.Tx, taskID int, parentID *int, userID int) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == taskID {
+		return ErrParentCycle
+	}
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1, $2)`, parentLockSpace, userID); err != nil {
+		return err
+	}
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+	if err := tx.QueryRow(query, *parentID, userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrInvalidParent
+	}
+	if taskID == 0 {
+		return nil
+	}
+
+	var cycle bool
+	query = `WITH RECURSIVE ancestors AS (
+                 SELECT id, parent_id FROM tasks WHERE id = $1
+                 UNION
+                 SELECT t.id, t.parent_id FROM tasks t JOIN ancestors a ON t.id = a.parent_id
+             )
+             SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`
+	if err := tx.QueryRow(query, *parentID, taskID).Scan(&cycle); err != nil {
+		return err
+	}
+	if cycle {
+		return ErrParentCycle
+	}
+	return nil
+}
+
+//! \fn checkProject(tx *sql.Tx, projectID, userID int) error
+//! \brief Verifies that a project exists, belongs to the user and is not archived.
+//! \param tx Open transaction.
+//! \param projectID ID of the project.
+//! \param userID ID of the task's owner.
+//! \return Error (if any).
+func checkProject(tx *sql.Tx, projectID, userID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND user_id = $2 AND archived_at IS NULL)`
+	if err := tx.QueryRow(query, projectID, userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrInvalidProject
+	}
+	return nil
+}
+
+//! \fn releaseChildren(tx *sql.Tx, task *models.Task, userID int, policy DeletePolicy) error
+//! \brief Applies a delete policy to the live subtasks of a task about to be trashed.
+//! \param tx Open transaction holding the task's row lock.
+//! \param task Task being deleted.
+//! \param userID ID of the user making the change.
+//! \param policy Delete policy.
+//! \return Error (if any).
+func (s *Service) releaseChildren(tx *sql.Tx, task *models.Task, userID int, policy DeletePolicy) error {
+	switch policy {
+	case DeleteReject:
+		var count int
+		query := `SELECT COUNT(*) FROM tasks WHERE parent_id = $1 AND deleted_at IS NULL`
+		if err := tx.QueryRow(query, task.ID).Scan(&count); err != nil {
+			s.logger.Error("Failed to count subtasks", zap.Error(err))
+			return err
+		}
+		if count > 0 {
+			return ErrHasChildren
+		}
+		return nil
+
+	case DeleteOrphan:
+		query := `UPDATE tasks SET parent_id = NULL, version = version + 1
+                  WHERE parent_id = $1 AND deleted_at IS NULL RETURNING id`
+		ids, err := queryIDs(tx, query, task.ID)
+		if err != nil {
+			s.logger.Error("Failed to orphan subtasks", zap.Error(err))
+			return err
+		}
+		change := map[string]models.FieldChange{"parent_id": {From: task.ID, To: nil}}
+		for _, id := range ids {
+			if err := s.recordEvent(tx, id, userID, ActionUpdated, change); err != nil {
+				s.logger.Error("Failed to record task event", zap.Error(err))
+				return err
+			}
+		}
+		return nil
+
+	case DeleteCascade:
+		query := `WITH RECURSIVE descendants AS (
+                      SELECT id FROM tasks WHERE parent_id = $1 AND deleted_at IS NULL
+                      UNION
+                      SELECT t.id FROM tasks t JOIN descendants d ON t.parent_id = d.id
+                      WHERE t.deleted_at IS NULL
+                  )
+                  UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+                  WHERE id IN (SELECT id FROM descendants) RETURNING id`
+		ids, err := queryIDs(tx, query, task.ID)
+		if err != nil {
+			s.logger.Error("Failed to delete subtasks", zap.Error(err))
+			return err
+		}
+		for _, id := range ids {
+			if err := s.recordEvent(tx, id, userID, ActionDeleted, nil); err != nil {
+				s.logger.Error("Failed to record task event", zap.Error(err))
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrInvalidDeletePolicy
+}
+
+//! \fn queryIDs(tx *sql.Tx, query string, args ...interface{}) ([]int, error)
+//! \brief Runs a query returning a single integer column.
+//! \param tx Open transaction.
+//! \param query SQL query.
+//! \param args Query arguments.
+//! \return IDs and error (if any).
+func queryIDs(tx *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+//! \fn GetChildren(taskID string, userID int) ([]models.Task, error)
+//! \brief Retrieves the direct subtasks of a user's task.
+//! \param taskID ID of the parent task.
+//! \param userID ID of the user.
+//! \return List of subtasks and error (if any).
+func (s *Service) GetChildren(taskID string, userID int) ([]models.Task, error) {
+	if _, err := s.GetTask(taskID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + taskColumns + ` FROM tasks
+              WHERE parent_id = $1 AND user_id = $2 AND deleted_at IS NULL ORDER BY id`
+	rows, err := s.db.Query(query, taskID, userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch subtasks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		var task models.Task
+		if err := scanTask(rows, &task); err != nil {
+			s.logger.Error("Failed to scan task", zap.Error(err))
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate subtasks", zap.Error(err))
+		return nil, err
+	}
+	return tasks, nil
+}
+
+//! \fn GetTree(taskID string, userID int) (*TaskNode, error)
+//! \brief Retrieves a user's task with all of its descendants.
+//! \param taskID ID of the root task.
+//! \param userID ID of the user.
+//! \return Root node of the tree and error (if any).
+func (s *Service) GetTree(taskID string, userID int) (*TaskNode, error) {
+	query := `WITH RECURSIVE tree AS (
+                  SELECT id FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+                  UNION
+                  SELECT t.id FROM tasks t JOIN tree ON t.parent_id = tree.id
+                  WHERE t.deleted_at IS NULL
+              )
+              SELECT ` + taskColumns + ` FROM tasks WHERE id IN (SELECT id FROM tree) ORDER BY id`
+	rows, err := s.db.Query(query, taskID, userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch task tree", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []*TaskNode
+	byID := map[int]*TaskNode{}
+	for rows.Next() {
+		node := &TaskNode{Children: []*TaskNode{}}
+		if err := scanTask(rows, &node.Task); err != nil {
+			s.logger.Error("Failed to scan task", zap.Error(err))
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		byID[node.ID] = node
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate task tree", zap.Error(err))
+		return nil, err
+	}
+
+	// Every node except the root has its parent in the result set.
+	var root *TaskNode
+	for _, node := range nodes {
+		if node.ParentID != nil {
+			if parent, ok := byID[*node.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		root = node
+	}
+	if root == nil {
+		s.logger.Warn("Task not found", zap.String("task_id", taskID))
+		return nil, sql.ErrNoRows
+	}
+
+	s.logger.Info("Task tree retrieved", zap.String("task_id", taskID), zap.Int("count", len(nodes)))
+	return root, nil
+}
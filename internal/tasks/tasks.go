@@ -1,7 +1,12 @@
 package tasks
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"task-tracker/internal/models"
 	"github.com/gin-gonic/gin"
@@ -10,20 +15,133 @@ import (
 )
 
 //! \fn GetTasksHandler(s *Service) gin.HandlerFunc
-//! \brief Creates a Gin handler to retrieve a user's tasks.
+//! \brief Creates a Gin handler to retrieve a filtered, sorted page of a user's tasks.
 //! \param s Task service instance.
 //! \return Gin handler function.
 func GetTasksHandler(s *Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		opts, err := parseListOptions(c)
+		if err != nil {
+			s.logger.Warn("Invalid list parameters", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		userID, _ := c.Get("user_id")
-		tasks, err := s.GetTasks(userID.(int))
+		tasks, nextCursor, err := s.GetTasks(userID.(int), opts)
 		if err != nil {
-			s.logger.Error("Failed to get tasks", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			respondError(c, s, err, "Internal server error")
 			return
 		}
-		c.JSON(http.StatusOK, tasks)
+		c.JSON(http.StatusOK, gin.H{"tasks": tasks, "next_cursor": nextCursor})
+	}
+}
+
+//! \fn GetProjectTasksHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to retrieve a filtered, sorted page of a project's tasks.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func GetProjectTasksHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		opts, err := parseListOptions(c)
+		if err != nil {
+			s.logger.Warn("Invalid list parameters", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		projectID, ok := pathID(c, "id", "Project not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		tasks, nextCursor, err := s.GetProjectTasks(projectID, userID.(int), opts)
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
+		if err != nil {
+			respondError(c, s, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"tasks": tasks, "next_cursor": nextCursor})
+	}
+}
+
+//! \fn parseListOptions(c *gin.Context) (ListOptions, error)
+//! \brief Reads filtering, sorting and pagination query parameters.
+//! \param c Gin context.
+//! \return List options and error (if any).
+func parseListOptions(c *gin.Context) (ListOptions, error) {
+	opts := ListOptions{
+		Sort:       c.Query("sort"),
+		Order:      c.Query("order"),
+		Cursor:     c.Query("cursor"),
+		Labels:     c.QueryArray("label"),
+		LabelMatch: c.Query("label_match"),
+	}
+
+	if status := c.Query("status"); status != "" {
+		for _, v := range strings.Split(status, ",") {
+			if !validStatuses[v] {
+				return opts, fmt.Errorf("invalid status %q", v)
+			}
+			opts.Statuses = append(opts.Statuses, v)
+		}
+	}
+
+	opts.IncludeArchived = c.Query("include_archived") == "true"
+
+	ints := map[string]**int{
+		"priority_min": &opts.PriorityMin,
+		"priority_max": &opts.PriorityMax,
+		"project_id":   &opts.ProjectID,
+	}
+	for name, dst := range ints {
+		if raw := c.Query(name); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s", name)
+			}
+			*dst = &v
+		}
+	}
+
+	times := map[string]**time.Time{
+		"due_after":      &opts.DueAfter,
+		"due_before":     &opts.DueBefore,
+		"created_after":  &opts.CreatedAfter,
+		"created_before": &opts.CreatedBefore,
+	}
+	for name, dst := range times {
+		if raw := c.Query(name); raw != "" {
+			v, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return opts, fmt.Errorf("invalid %s: expected RFC 3339 timestamp", name)
+			}
+			v = v.UTC()
+			*dst = &v
+		}
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 {
+			return opts, fmt.Errorf("invalid limit")
+		}
+		opts.Limit = v
 	}
+
+	return opts, nil
+}
+
+//! \fn validateTask(task *models.Task) error
+//! \brief Applies the field validation rules shared by every endpoint that writes tasks.
+//! \param task Task to validate.
+//! \return Validation errors (if any).
+func validateTask(task *models.Task) error {
+	validate := validator.New()
+	return validate.Struct(task)
 }
 
 //! \fn CreateTaskHandler(s *Service) gin.HandlerFunc
@@ -39,8 +157,7 @@ func CreateTaskHandler(s *Service) gin.HandlerFunc {
 			return
 		}
 
-		validate := validator.New()
-		if err := validate.Struct(&task); err != nil {
+		if err := validateTask(&task); err != nil {
 			s.logger.Warn("Validation failed", zap.Error(err))
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -50,8 +167,7 @@ func CreateTaskHandler(s *Service) gin.HandlerFunc {
 		task.UserID = userID.(int)
 		taskID, err := s.CreateTask(&task)
 		if err != nil {
-			s.logger.Error("Failed to create task", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+			respondError(c, s, err, "Failed to create task")
 			return
 		}
 
@@ -59,13 +175,32 @@ func CreateTaskHandler(s *Service) gin.HandlerFunc {
 	}
 }
 
+//! \fn pathID(c *gin.Context, name, notFound string) (string, bool)
+//! \brief Reads a numeric path parameter. Anything else cannot name a row, so it is answered with 404
+//!        here instead of failing as a type error in the database.
+//! \param c Gin context.
+//! \param name Name of the path parameter.
+//! \param notFound Error message of the 404 response.
+//! \return Parameter value and whether the handler may continue.
+func pathID(c *gin.Context, name, notFound string) (string, bool) {
+	id := c.Param(name)
+	if _, err := strconv.ParseInt(id, 10, 32); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return "", false
+	}
+	return id, true
+}
+
 //! \fn GetTaskHandler(s *Service) gin.HandlerFunc
 //! \brief Creates a Gin handler to retrieve a specific task.
 //! \param s Task service instance.
 //! \return Gin handler function.
 func GetTaskHandler(s *Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		taskID := c.Param("id")
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
 		userID, _ := c.Get("user_id")
 		task, err := s.GetTask(taskID, userID.(int))
 		if err != nil {
@@ -73,6 +208,7 @@ func GetTaskHandler(s *Service) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 			return
 		}
+		c.Header("ETag", etag(task.Version))
 		c.JSON(http.StatusOK, task)
 	}
 }
@@ -83,46 +219,536 @@ func GetTaskHandler(s *Service) gin.HandlerFunc {
 //! \return Gin handler function.
 func UpdateTaskHandler(s *Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		taskID := c.Param("id")
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
 		userID, _ := c.Get("user_id")
+		cond, ok := preconditionFrom(c, s)
+		if !ok {
+			return
+		}
+		body, err := c.GetRawData()
+		if err != nil {
+			s.logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
 		var task models.Task
-		if err := c.ShouldBindJSON(&task); err != nil {
+		if err := json.Unmarshal(body, &task); err != nil {
 			s.logger.Warn("Invalid request body", zap.Error(err))
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 			return
 		}
 
-		validate := validator.New()
-		if err := validate.Struct(&task); err != nil {
+		if err := validateTask(&task); err != nil {
 			s.logger.Warn("Validation failed", zap.Error(err))
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
-		err := s.UpdateTask(&task, taskID, userID.(int))
-		if err != nil {
-			s.logger.Warn("Task not found", zap.Error(err))
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		if err := s.UpdateTask(&task, taskID, userID.(int), cond, OmittedFields(body)); err != nil {
+			respondError(c, s, err, "Failed to update task")
 			return
 		}
+		c.Header("ETag", etag(task.Version))
 		c.JSON(http.StatusOK, gin.H{"message": "Task updated"})
 	}
 }
 
 //! \fn DeleteTaskHandler(s *Service) gin.HandlerFunc
-//! \brief Creates a Gin handler to delete a task.
+//! \brief Creates a Gin handler to delete a task; the children query parameter selects the subtask policy.
 //! \param s Task service instance.
 //! \return Gin handler function.
 func DeleteTaskHandler(s *Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		taskID := c.Param("id")
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
 		userID, _ := c.Get("user_id")
-		err := s.DeleteTask(taskID, userID.(int))
+		cond, ok := preconditionFrom(c, s)
+		if !ok {
+			return
+		}
+		policy, err := ParseDeletePolicy(c.Query("children"))
 		if err != nil {
-			s.logger.Warn("Task not found", zap.Error(err))
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			respondError(c, s, err, "Failed to delete task")
+			return
+		}
+		if err := s.DeleteTask(taskID, userID.(int), cond, policy); err != nil {
+			respondError(c, s, err, "Failed to delete task")
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"message": "Task deleted"})
 	}
-}
\ No newline at end of file
+}
+
+//! \fn SearchTasksHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler for full-text search over a user's tasks.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func SearchTasksHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrEmptyQuery.Error()})
+			return
+		}
+
+		limit := 0
+		if raw := c.Query("limit"); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil || v < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+				return
+			}
+			limit = v
+		}
+
+		userID, _ := c.Get("user_id")
+		results, err := s.SearchTasks(userID.(int), q, limit)
+		if err != nil {
+			s.logger.Error("Failed to search tasks", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, results)
+	}
+}
+
+//! \fn PatchTaskHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to partially update a task with a JSON Merge Patch.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func PatchTaskHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.ContentType() != "application/merge-patch+json" {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be application/merge-patch+json"})
+			return
+		}
+
+		patch, err := c.GetRawData()
+		if err != nil {
+			s.logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		cond, ok := preconditionFrom(c, s)
+		if !ok {
+			return
+		}
+		task, err := s.PatchTask(taskID, userID.(int), patch, cond)
+		if err != nil {
+			respondError(c, s, err, "Failed to update task")
+			return
+		}
+		c.Header("ETag", etag(task.Version))
+		c.JSON(http.StatusOK, task)
+	}
+}
+
+//! \fn GetTrashHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list a user's trashed tasks.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func GetTrashHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		tasks, err := s.GetTrash(userID.(int))
+		if err != nil {
+			s.logger.Error("Failed to get trash", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, tasks)
+	}
+}
+
+//! \fn RestoreTaskHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to restore a task from the trash.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func RestoreTaskHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, ok := pathID(c, "id", "Task not found in trash")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		task, err := s.RestoreTask(taskID, userID.(int))
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found in trash"})
+			return
+		}
+		if err != nil {
+			respondError(c, s, err, "Failed to restore task")
+			return
+		}
+		c.Header("ETag", etag(task.Version))
+		c.JSON(http.StatusOK, task)
+	}
+}
+
+//! \fn GetHistoryHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to retrieve a task's change history.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func GetHistoryHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		events, err := s.GetHistory(taskID, userID.(int))
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to get task history", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, events)
+	}
+}
+
+//! \fn GetChildrenHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list the direct subtasks of a task.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func GetChildrenHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		tasks, err := s.GetChildren(taskID, userID.(int))
+		if err != nil {
+			respondError(c, s, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, tasks)
+	}
+}
+
+//! \fn GetTreeHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to retrieve a task with all of its descendants.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func GetTreeHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		tree, err := s.GetTree(taskID, userID.(int))
+		if err != nil {
+			respondError(c, s, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, tree)
+	}
+}
+
+//! \fn AddLinkHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to link a task to another task.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func AddLinkHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var link models.TaskLink
+		if err := c.ShouldBindJSON(&link); err != nil {
+			s.logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&link); err != nil {
+			s.logger.Warn("Validation failed", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		if err := s.AddLink(taskID, userID.(int), &link); err != nil {
+			respondError(c, s, err, "Failed to create link")
+			return
+		}
+		c.JSON(http.StatusCreated, link)
+	}
+}
+
+//! \fn GetLinksHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list the links from or to a task.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func GetLinksHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		links, err := s.GetLinks(taskID, userID.(int))
+		if err != nil {
+			respondError(c, s, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, links)
+	}
+}
+
+//! \fn RemoveLinkHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to remove a link from a task.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func RemoveLinkHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		linkID, ok := pathID(c, "link_id", "Link not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		err := s.RemoveLink(taskID, linkID, userID.(int))
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+			return
+		}
+		if err != nil {
+			respondError(c, s, err, "Failed to remove link")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Link removed"})
+	}
+}
+
+//! \fn AttachLabelHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to attach a label to a task.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func AttachLabelHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		labelID, ok := pathID(c, "label_id", "Label not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		if err := s.AttachLabel(taskID, labelID, userID.(int)); err != nil {
+			respondError(c, s, err, "Failed to attach label")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Label attached"})
+	}
+}
+
+//! \fn DetachLabelHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to detach a label from a task.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func DetachLabelHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		labelID, ok := pathID(c, "label_id", "Label not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		if err := s.DetachLabel(taskID, labelID, userID.(int)); err != nil {
+			respondError(c, s, err, "Failed to detach label")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Label detached"})
+	}
+}
+
+//! \fn GetOccurrencesHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to preview the next due dates of a recurring task.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func GetOccurrencesHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count := 0
+		if raw := c.Query("count"); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil || v < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid count"})
+				return
+			}
+			count = v
+		}
+
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		occurrences, err := s.GetOccurrences(taskID, userID.(int), count)
+		if err != nil {
+			respondError(c, s, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"occurrences": occurrences})
+	}
+}
+
+//! \fn StopRecurrenceHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to stop a task from recurring.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func StopRecurrenceHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		taskID, ok := pathID(c, "id", "Task not found")
+		if !ok {
+			return
+		}
+		userID, _ := c.Get("user_id")
+		cond, ok := preconditionFrom(c, s)
+		if !ok {
+			return
+		}
+		task, err := s.StopRecurrence(taskID, userID.(int), cond)
+		if err != nil {
+			respondError(c, s, err, "Failed to update task")
+			return
+		}
+		c.Header("ETag", etag(task.Version))
+		c.JSON(http.StatusOK, task)
+	}
+}
+
+//! \fn BulkTasksHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to run a batch of task operations in one transaction.
+//!        A failed atomic batch answers with the failing operation's status.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func BulkTasksHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BulkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			s.logger.Warn("Invalid request body", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		results, committed, err := s.BulkTasks(userID.(int), &req)
+		if err != nil {
+			respondError(c, s, err, "Failed to apply bulk operations")
+			return
+		}
+		if !committed {
+			failed := results[len(results)-1]
+			c.JSON(failed.Status, gin.H{"committed": false, "error": failed.Error, "index": failed.Index, "results": results})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"committed": true, "results": results})
+	}
+}
+
+//! \fn ExportTasksHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler streaming all of a user's tasks as CSV or JSON (format query parameter).
+//! \param s Task service instance.
+//! \return Gin handler function.
+func ExportTasksHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", FormatJSON)
+		w, err := newExportWriter(format, c.Writer)
+		if err != nil {
+			respondError(c, s, err, "Failed to export tasks")
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		started := false
+		start := func() error {
+			started = true
+			filename := "tasks-" + time.Now().UTC().Format("20060102") + "." + format
+			c.Header("Content-Type", w.contentType())
+			c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+			c.Status(http.StatusOK)
+			return w.begin()
+		}
+		err = s.ExportTasks(userID.(int), func(rec TaskRecord) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			return w.write(rec)
+		})
+		if err == nil && !started {
+			err = start()
+		}
+		if err == nil {
+			err = w.end()
+		}
+		if err != nil {
+			if !started {
+				respondError(c, s, err, "Failed to export tasks")
+				return
+			}
+			// The status line is already sent; cutting the stream short is all that is left.
+			s.logger.Error("Export interrupted", zap.Error(err))
+			c.Abort()
+		}
+	}
+}
+
+//! \fn ImportTasksHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler importing tasks from a CSV or JSON request body.
+//!        The format comes from the format query parameter or the Content-Type; dry_run=true only validates.
+//! \param s Task service instance.
+//! \return Gin handler function.
+func ImportTasksHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.Query("format")
+		if format == "" {
+			switch c.ContentType() {
+			case "text/csv":
+				format = FormatCSV
+			case "application/json":
+				format = FormatJSON
+			}
+		}
+		dryRun := c.Query("dry_run") == "true"
+
+		userID, _ := c.Get("user_id")
+		report, err := s.ImportTasks(userID.(int), c.Request.Body, format, dryRun)
+		if err != nil {
+			respondError(c, s, err, "Failed to import tasks")
+			return
+		}
+		status := http.StatusOK
+		if !report.Committed && !report.DryRun {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, report)
+	}
+}
@@ -0,0 +1,41 @@
+package tasks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPathID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		path     string
+		wantOK   bool
+		wantCode int
+	}{
+		{"numeric", "/tasks/42", true, http.StatusOK},
+		{"not numeric", "/tasks/abc", false, http.StatusNotFound},
+		{"trailing garbage", "/tasks/42abc", false, http.StatusNotFound},
+		{"beyond INT", "/tasks/2147483648", false, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ok bool
+			r := gin.New()
+			r.GET("/tasks/:id", func(c *gin.Context) {
+				_, ok = pathID(c, "id", "Task not found")
+				if ok {
+					c.Status(http.StatusOK)
+				}
+			})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if ok != tt.wantOK || w.Code != tt.wantCode {
+				t.Errorf("GET %s: ok = %v, status %d, want %v, %d", tt.path, ok, w.Code, tt.wantOK, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,587 @@
+package tasks
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \brief Supported import and export formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+//! \const maxImportErrors
+//! \brief Number of row errors reported by an import; counting goes on past it.
+const maxImportErrors = 100
+
+//! \var ErrInvalidFormat
+//! \brief Returned when an unsupported import or export format is requested.
+var ErrInvalidFormat = errors.New("format must be csv or json")
+
+//! \var ErrInvalidImport
+//! \brief Returned when an import document cannot be read at all.
+var ErrInvalidImport = errors.New("invalid import document")
+
+//! \var csvColumns
+//! \brief Columns of the CSV format, in export order.
+var csvColumns = []string{"id", "title", "description", "status", "priority", "due_date",
+	"parent_id", "project_id", "recurrence", "created_at"}
+
+//! \struct TaskRecord
+//! \brief Portable form of a task used by both formats. IDs let an import keep
+//!        parent links between tasks of the same document.
+type TaskRecord struct {
+	ID          int        `json:"id,omitempty"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	Priority    int        `json:"priority"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	ParentID    *int       `json:"parent_id,omitempty"`
+	ProjectID   int        `json:"project_id,omitempty"`
+	Recurrence  *string    `json:"recurrence,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+//! \fn toRecord(task *models.Task) TaskRecord
+//! \brief Converts a stored task into its portable form.
+//! \param task Task to convert.
+//! \return Record.
+func toRecord(task *models.Task) TaskRecord {
+	rec := TaskRecord{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		ParentID:    task.ParentID,
+		ProjectID:   task.ProjectID,
+		Recurrence:  task.Recurrence,
+		DueDate:     task.DueDate,
+		CreatedAt:   &task.CreatedAt,
+	}
+	return rec
+}
+
+//! \fn toTask(userID int) *models.Task
+//! \brief Converts an imported record into a new task for a user.
+//! \param userID ID of the importing user.
+//! \return Task.
+func (r *TaskRecord) toTask(userID int) *models.Task {
+	task := &models.Task{
+		UserID:      userID,
+		Title:       r.Title,
+		Description: r.Description,
+		Status:      r.Status,
+		Priority:    r.Priority,
+		ParentID:    r.ParentID,
+		ProjectID:   r.ProjectID,
+		DueDate:     r.DueDate,
+		Recurrence:  r.Recurrence,
+	}
+	return task
+}
+
+//! \fn isFormula(value string) bool
+//! \brief Reports whether a spreadsheet would evaluate a cell as a formula, or whether the cell
+//!        already looks like one escaped by csvText, which must be escaped again to round-trip.
+//! \param value Cell value.
+//! \return Whether csvText prefixes the value.
+func isFormula(value string) bool {
+	if value == "" {
+		return false
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return true
+	case '\'':
+		return isFormula(value[1:])
+	}
+	return false
+}
+
+//! \fn csvText(value string) string
+//! \brief Escapes a free-text cell against formula injection by prefixing "'", which spreadsheets hide.
+//! \param value Cell value.
+//! \return Escaped value.
+func csvText(value string) string {
+	if isFormula(value) {
+		return "'" + value
+	}
+	return value
+}
+
+//! \fn parseCSVText(value string) string
+//! \brief Removes the prefix added by csvText.
+//! \param value Cell value.
+//! \return Original value.
+func parseCSVText(value string) string {
+	if strings.HasPrefix(value, "'") && isFormula(value[1:]) {
+		return value[1:]
+	}
+	return value
+}
+
+//! \fn csvRow() []string
+//! \brief Formats a record as a CSV row in csvColumns order.
+//! \return Row fields.
+func (r *TaskRecord) csvRow() []string {
+	row := []string{strconv.Itoa(r.ID), csvText(r.Title), csvText(r.Description), r.Status,
+		strconv.Itoa(r.Priority), "", "", "", "", ""}
+	if r.DueDate != nil {
+		row[5] = r.DueDate.UTC().Format(time.RFC3339)
+	}
+	if r.ParentID != nil {
+		row[6] = strconv.Itoa(*r.ParentID)
+	}
+	if r.ProjectID != 0 {
+		row[7] = strconv.Itoa(r.ProjectID)
+	}
+	if r.Recurrence != nil {
+		row[8] = *r.Recurrence
+	}
+	if r.CreatedAt != nil {
+		row[9] = r.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return row
+}
+
+//! \fn ExportTasks(userID int, emit func(TaskRecord) error) error
+//! \brief Streams a user's live tasks, oldest first, without loading them all into memory.
+//! \param userID ID of the user.
+//! \param emit Called for each task; an error stops the export.
+//! \return Error (if any).
+func (s *Service) ExportTasks(userID int, emit func(TaskRecord) error) error {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE user_id = $1 AND deleted_at IS NULL ORDER BY id`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch tasks for export", zap.Error(err))
+		return err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var task models.Task
+		if err := scanTask(rows, &task); err != nil {
+			s.logger.Error("Failed to scan task", zap.Error(err))
+			return err
+		}
+		if err := emit(toRecord(&task)); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate tasks", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Tasks exported", zap.Int("user_id", userID), zap.Int("count", count))
+	return nil
+}
+
+//! \interface exportWriter
+//! \brief Encodes a stream of records in one export format.
+type exportWriter interface {
+	//! \brief Media type of the document.
+	contentType() string
+	//! \brief Writes whatever precedes the first record.
+	begin() error
+	//! \brief Writes one record.
+	write(rec TaskRecord) error
+	//! \brief Finishes the document and flushes buffered output.
+	end() error
+}
+
+//! \fn newExportWriter(format string, w io.Writer) (exportWriter, error)
+//! \brief Creates the writer for an export format.
+//! \param format FormatCSV or FormatJSON.
+//! \param w Destination.
+//! \return Writer and error (if any).
+func newExportWriter(format string, w io.Writer) (exportWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvExportWriter{w: csv.NewWriter(w)}, nil
+	case FormatJSON:
+		return &jsonExportWriter{w: w}, nil
+	}
+	return nil, ErrInvalidFormat
+}
+
+//! \struct csvExportWriter
+//! \brief Writes records as CSV rows below a header row.
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+//! \fn contentType() string
+//! \brief Media type of a CSV export.
+//! \return Content type.
+func (e *csvExportWriter) contentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+//! \fn begin() error
+//! \brief Writes the header row.
+//! \return Error (if any).
+func (e *csvExportWriter) begin() error {
+	return e.w.Write(csvColumns)
+}
+
+//! \fn write(rec TaskRecord) error
+//! \brief Writes one task as a row.
+//! \param rec Task record.
+//! \return Error (if any).
+func (e *csvExportWriter) write(rec TaskRecord) error {
+	return e.w.Write(rec.csvRow())
+}
+
+//! \fn end() error
+//! \brief Flushes the buffered rows.
+//! \return Error (if any).
+func (e *csvExportWriter) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+//! \struct jsonExportWriter
+//! \brief Writes records as the elements of a JSON array.
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+//! \fn contentType() string
+//! \brief Media type of a JSON export.
+//! \return Content type.
+func (e *jsonExportWriter) contentType() string {
+	return "application/json; charset=utf-8"
+}
+
+//! \fn begin() error
+//! \brief Opens the array.
+//! \return Error (if any).
+func (e *jsonExportWriter) begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+//! \fn write(rec TaskRecord) error
+//! \brief Writes one task as an array element, one per line.
+//! \param rec Task record.
+//! \return Error (if any).
+func (e *jsonExportWriter) write(rec TaskRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "\n"
+	}
+	e.count++
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+//! \fn end() error
+//! \brief Closes the array.
+//! \return Error (if any).
+func (e *jsonExportWriter) end() error {
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+//! \struct rowError
+//! \brief A problem confined to one row of an import document.
+type rowError struct {
+	err error
+}
+
+//! \fn Error() string
+//! \brief Describes the problem with the row.
+//! \return Error message.
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+//! \fn Unwrap() error
+//! \brief Exposes the underlying error.
+//! \return Wrapped error.
+func (e *rowError) Unwrap() error {
+	return e.err
+}
+
+//! \interface recordReader
+//! \brief Reads the records of an import document one at a time.
+type recordReader interface {
+	//! \brief Returns the next record, a *rowError for a bad row, io.EOF at the end,
+	//!        or any other error if the document is unreadable.
+	next() (*TaskRecord, error)
+}
+
+//! \struct csvReader
+//! \brief Reads records from CSV with a header row naming the columns.
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+//! \fn newCSVReader(r io.Reader) (*csvReader, error)
+//! \brief Reads the header row of a CSV document. Unknown columns are ignored.
+//! \param r Document.
+//! \return Reader and error (if any).
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing header row", ErrInvalidImport)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("%w: missing title column", ErrInvalidImport)
+	}
+	return &csvReader{r: cr, columns: columns}, nil
+}
+
+//! \fn next() (*TaskRecord, error)
+//! \brief Parses the next CSV row.
+//! \return Record and error (if any).
+func (c *csvReader) next() (*TaskRecord, error) {
+	row, err := c.r.Read()
+	if err != nil {
+		// The reader resumes at the next record after a parse error.
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, &rowError{err}
+		}
+		if err != io.EOF {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		return nil, err
+	}
+	field := func(name string) string {
+		if i, ok := c.columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	rec := &TaskRecord{Title: parseCSVText(field("title")), Status: field("status")}
+	if i, ok := c.columns["description"]; ok && i < len(row) {
+		rec.Description = parseCSVText(row[i])
+	}
+	ints := map[string]*int{"id": &rec.ID, "priority": &rec.Priority, "project_id": &rec.ProjectID}
+	for name, dst := range ints {
+		if raw := field(name); raw != "" {
+			v, err := strconv.Atoi(raw)
+			if err != nil {
+				return nil, &rowError{fmt.Errorf("invalid %s %q", name, raw)}
+			}
+			*dst = v
+		}
+	}
+	if raw := field("parent_id"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, &rowError{fmt.Errorf("invalid parent_id %q", raw)}
+		}
+		rec.ParentID = &v
+	}
+	if raw := field("due_date"); raw != "" {
+		v, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, &rowError{fmt.Errorf("invalid due_date %q: expected RFC 3339 timestamp", raw)}
+		}
+		rec.DueDate = &v
+	}
+	if raw := field("recurrence"); raw != "" {
+		rec.Recurrence = &raw
+	}
+	return rec, nil
+}
+
+//! \struct jsonReader
+//! \brief Reads records from a JSON array one element at a time.
+type jsonReader struct {
+	dec *json.Decoder
+}
+
+//! \fn newJSONReader(r io.Reader) (*jsonReader, error)
+//! \brief Consumes the opening bracket of a JSON array.
+//! \param r Document.
+//! \return Reader and error (if any).
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("%w: expected a JSON array", ErrInvalidImport)
+	}
+	return &jsonReader{dec: dec}, nil
+}
+
+//! \fn next() (*TaskRecord, error)
+//! \brief Decodes the next array element.
+//! \return Record and error (if any).
+func (j *jsonReader) next() (*TaskRecord, error) {
+	if !j.dec.More() {
+		if _, err := j.dec.Token(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		return nil, io.EOF
+	}
+	var rec TaskRecord
+	if err := j.dec.Decode(&rec); err != nil {
+		// Type mismatches leave the decoder positioned after the element; syntax errors do not.
+		var typeErr *json.UnmarshalTypeError
+		var timeErr *time.ParseError
+		if errors.As(err, &typeErr) || errors.As(err, &timeErr) {
+			return nil, &rowError{err}
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	return &rec, nil
+}
+
+//! \struct ImportError
+//! \brief Why one row of an import was rejected. Rows are numbered from 1, excluding the CSV header.
+type ImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+//! \struct ImportReport
+//! \brief Outcome of an import.
+type ImportReport struct {
+	DryRun          bool          `json:"dry_run"`
+	Committed       bool          `json:"committed"`
+	Total           int           `json:"total"`
+	Valid           int           `json:"valid"`
+	Errors          []ImportError `json:"errors"`
+	ErrorsTruncated bool          `json:"errors_truncated,omitempty"`
+}
+
+//! \fn addError(row int, message string)
+//! \brief Records a rejected row, keeping at most maxImportErrors messages.
+//! \param row Row number.
+//! \param message Reason.
+func (r *ImportReport) addError(row int, message string) {
+	if len(r.Errors) == maxImportErrors {
+		r.ErrorsTruncated = true
+		return
+	}
+	r.Errors = append(r.Errors, ImportError{Row: row, Error: message})
+}
+
+//! \fn ImportTasks(userID int, body io.Reader, format string, dryRun bool) (*ImportReport, error)
+//! \brief Creates tasks from a CSV or JSON document in one transaction, reading it row by row.
+//!        Every row is validated like a created task; the import is committed only if all rows
+//!        are valid and dryRun is false. Parent IDs that match an earlier row's id are mapped
+//!        to the task created for it; others must name an existing task.
+//! \param userID ID of the user.
+//! \param body Import document.
+//! \param format FormatCSV or FormatJSON.
+//! \param dryRun Whether to validate without committing.
+//! \return Report and error (if any).
+func (s *Service) ImportTasks(userID int, body io.Reader, format string, dryRun bool) (*ImportReport, error) {
+	var reader recordReader
+	var err error
+	switch format {
+	case FormatCSV:
+		reader, err = newCSVReader(body)
+	case FormatJSON:
+		reader, err = newJSONReader(body)
+	default:
+		return nil, ErrInvalidFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &ImportReport{DryRun: dryRun, Errors: []ImportError{}}
+	created := map[int]int{}
+	failed := 0
+	for row := 1; ; row++ {
+		rec, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		report.Total++
+		var bad *rowError
+		if errors.As(err, &bad) {
+			failed++
+			report.addError(row, bad.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if rec.ParentID != nil {
+			if id, ok := created[*rec.ParentID]; ok {
+				rec.ParentID = &id
+			}
+		}
+		task := rec.toTask(userID)
+		if err := validateTask(task); err != nil {
+			failed++
+			report.addError(row, err.Error())
+			continue
+		}
+		taskID, err := s.createTask(tx, task)
+		if err != nil {
+			// Only domain errors leave the transaction usable for the following rows.
+			status, message := describeError(err, "")
+			if status == http.StatusInternalServerError {
+				return nil, err
+			}
+			failed++
+			report.addError(row, message)
+			continue
+		}
+		if rec.ID != 0 {
+			created[rec.ID] = taskID
+		}
+	}
+	report.Valid = report.Total - failed
+
+	if dryRun || failed > 0 {
+		s.logger.Info("Tasks import not committed", zap.Int("user_id", userID), zap.Bool("dry_run", dryRun),
+			zap.Int("rows", report.Total), zap.Int("invalid", failed))
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+	report.Committed = true
+
+	s.logger.Info("Tasks imported", zap.Int("user_id", userID), zap.Int("count", report.Total))
+	return report, nil
+}
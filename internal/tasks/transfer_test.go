@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Pay rent", "Pay rent"},
+		{"", ""},
+		{"=HYPERLINK(\"http://evil.example\")", "'=HYPERLINK(\"http://evil.example\")"},
+		{"+1 call", "'+1 call"},
+		{"-2 days", "'-2 days"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tindented", "'\tindented"},
+		{"'quoted'", "'quoted'"},
+		{"'=already escaped", "''=already escaped"},
+		{"a = b", "a = b"},
+	}
+	for _, tt := range tests {
+		got := csvText(tt.value)
+		if got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+		if back := parseCSVText(got); back != tt.value {
+			t.Errorf("parseCSVText(%q) = %q, want %q", got, back, tt.value)
+		}
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	rec := TaskRecord{ID: 1, Title: "=1+1", Description: "-rf everything", Status: "pending", Priority: 2}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvColumns); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(rec.csvRow()); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+
+	r, err := newCSVReader(&buf)
+	if err != nil {
+		t.Fatalf("newCSVReader() error = %v", err)
+	}
+	got, err := r.next()
+	if err != nil {
+		t.Fatalf("next() error = %v", err)
+	}
+	if got.Title != rec.Title || got.Description != rec.Description {
+		t.Errorf("round trip gave title %q, description %q", got.Title, got.Description)
+	}
+}
@@ -0,0 +1,201 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"task-tracker/internal/models"
+	"go.uber.org/zap"
+)
+
+//! \const purgeInterval
+//! \brief How often the background purge looks for expired trash.
+const purgeInterval = time.Hour
+
+//! \const purgeBatchSize
+//! \brief Number of tasks purged per transaction.
+const purgeBatchSize = 500
+
+//! \var ErrParentTrashed
+//! \brief Returned when restoring a subtask whose parent is still in the trash.
+var ErrParentTrashed = errors.New("parent task is in the trash; restore it first")
+
+//! \var ErrProjectArchived
+//! \brief Returned when restoring a task whose project is archived.
+var ErrProjectArchived = errors.New("project is archived; unarchive it first")
+
+//! \fn GetTrash(userID int) ([]models.Task, error)
+//! \brief Retrieves a user's trashed tasks, most recently deleted first.
+//! \param userID ID of the user.
+//! \return List of trashed tasks and error (if any).
+func (s *Service) GetTrash(userID int) ([]models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks
+              WHERE user_id = $1 AND deleted_at IS NOT NULL
+              ORDER BY deleted_at DESC, id DESC`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch trash", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		var task models.Task
+		if err := scanTask(rows, &task); err != nil {
+			s.logger.Error("Failed to scan task", zap.Error(err))
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate trash", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Trash retrieved", zap.Int("user_id", userID), zap.Int("count", len(tasks)))
+	return tasks, nil
+}
+
+//! \fn RestoreTask(taskID string, userID int) (*models.Task, error)
+//! \brief Moves a task out of the trash, provided its parent and project are live.
+//! \param taskID ID of the task.
+//! \param userID ID of the user.
+//! \return Restored task and error (if any).
+func (s *Service) RestoreTask(taskID string, userID int) (*models.Task, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Error("Failed to begin transaction", zap.Error(err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var task models.Task
+	query := `UPDATE tasks SET deleted_at = NULL, version = version + 1
+              WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+              RETURNING ` + taskColumns
+	if err := scanTask(tx.QueryRow(query, taskID, userID), &task); err != nil {
+		s.logger.Warn("Task not found in trash", zap.String("task_id", taskID), zap.Error(err))
+		return nil, err
+	}
+	if err := checkParent(tx, task.ID, task.ParentID, userID); err != nil {
+		if errors.Is(err, ErrInvalidParent) {
+			err = ErrParentTrashed
+		}
+		s.logger.Warn("Cannot restore task", zap.String("task_id", taskID), zap.Error(err))
+		return nil, err
+	}
+	if err := checkProject(tx, task.ProjectID, userID); err != nil {
+		if errors.Is(err, ErrInvalidProject) {
+			err = ErrProjectArchived
+		}
+		s.logger.Warn("Cannot restore task", zap.String("task_id", taskID), zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.recordEvent(tx, task.ID, userID, ActionRestored, nil); err != nil {
+		s.logger.Error("Failed to record task event", zap.Error(err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Error("Failed to commit transaction", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Task restored", zap.String("task_id", taskID), zap.Int("user_id", userID))
+	return &task, nil
+}
+
+//! \fn PurgeTrash(retention time.Duration) (int64, error)
+//! \brief Permanently deletes tasks that have been in the trash longer than the retention period.
+//! \param retention How long trashed tasks are kept.
+//! \return Number of purged tasks and error (if any).
+func (s *Service) PurgeTrash(retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	var purged int64
+	for {
+		n, err := s.purgeBatch(cutoff)
+		purged += int64(n)
+		if err != nil {
+			s.logger.Error("Failed to purge trash", zap.Error(err))
+			return purged, err
+		}
+		if n < purgeBatchSize {
+			break
+		}
+	}
+
+	if purged > 0 {
+		s.logger.Info("Trash purged", zap.Int64("count", purged))
+	}
+	return purged, nil
+}
+
+//! \fn purgeBatch(cutoff time.Time) (int, error)
+//! \brief Deletes up to purgeBatchSize tasks trashed before the cutoff, recording and publishing
+//!        each purge. Purges have no actor; history outlives the task itself.
+//! \param cutoff Tasks trashed before this time are purged.
+//! \return Number of purged tasks and error (if any).
+func (s *Service) purgeBatch(cutoff time.Time) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < $1
+              ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED`
+	rows, err := tx.Query(query, cutoff, purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	var batch []models.Task
+	for rows.Next() {
+		var task models.Task
+		if err := scanTask(rows, &task); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, task)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i := range batch {
+		task := &batch[i]
+		var eventID int64
+		query := `INSERT INTO task_events (task_id, user_id, action) VALUES ($1, $2, $3) RETURNING id`
+		if err := tx.QueryRow(query, task.ID, task.UserID, ActionPurged).Scan(&eventID); err != nil {
+			return 0, err
+		}
+		if err := s.publishTask(tx, eventID, task, 0, ActionPurged, map[string]models.FieldChange{}); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`DELETE FROM tasks WHERE id = $1`, task.ID); err != nil {
+			return 0, err
+		}
+	}
+	return len(batch), tx.Commit()
+}
+
+//! \fn RunTrashPurger(ctx context.Context, retention time.Duration)
+//! \brief Periodically purges expired trash until the context is cancelled.
+//! \param ctx Context controlling the purger's lifetime.
+//! \param retention How long trashed tasks are kept.
+func (s *Service) RunTrashPurger(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		s.PurgeTrash(retention)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
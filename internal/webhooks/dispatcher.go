@@ -0,0 +1,199 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"task-tracker/internal/safehttp"
+	"task-tracker/internal/signing"
+	"go.uber.org/zap"
+)
+
+const (
+	dispatchInterval  = 5 * time.Second
+	dispatchBatchSize = 50
+	dispatchLease     = time.Minute
+	maxAttempts       = 8
+	baseBackoff       = 30 * time.Second
+	maxBackoff        = time.Hour
+)
+
+//! \struct sender
+//! \brief Posts signed payloads to webhook endpoints.
+type sender struct {
+	client *http.Client
+}
+
+//! \fn newSender() *sender
+//! \brief Initializes a sender with a bounded request timeout that cannot reach internal addresses.
+//! \return Pointer to initialized sender.
+func newSender() *sender {
+	return &sender{client: safehttp.NewClient(10 * time.Second)}
+}
+
+//! \fn send(ctx context.Context, target, secret, event string, deliveryID int64, body []byte) (int, error)
+//! \brief Posts one delivery.
+//! \param ctx Delivery context.
+//! \param target Endpoint URL.
+//! \param secret Signing secret.
+//! \param event Event type.
+//! \param deliveryID ID of the delivery, sent so receivers can deduplicate.
+//! \param body JSON payload.
+//! \return HTTP status code (0 if no response) and error unless the endpoint answered 2xx.
+func (s *sender) send(ctx context.Context, target, secret, event string, deliveryID int64, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(deliveryID, 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", signing.Sign(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+//! \struct claim
+//! \brief A leased delivery together with its endpoint.
+type claim struct {
+	id       int64
+	event    string
+	payload  []byte
+	attempts int
+	url      string
+	secret   string
+}
+
+//! \fn claimDue(ctx context.Context, now time.Time) ([]claim, error)
+//! \brief Leases a batch of pending deliveries whose next attempt is due. SKIP LOCKED keeps
+//!        dispatchers on other instances off the same rows; the lease expires if this one dies.
+//! \param ctx Context controlling the query.
+//! \param now Current time in UTC.
+//! \return Claimed deliveries and error (if any).
+func (s *Service) claimDue(ctx context.Context, now time.Time) ([]claim, error) {
+	query := `WITH claimed AS (
+                  UPDATE webhook_deliveries SET next_attempt_at = $2, attempts = attempts + 1
+                  WHERE id IN (SELECT id FROM webhook_deliveries
+                               WHERE status = 'pending' AND next_attempt_at <= $1
+                               ORDER BY next_attempt_at LIMIT $3
+                               FOR UPDATE SKIP LOCKED)
+                  RETURNING id, webhook_id, event, payload, attempts
+              )
+              SELECT c.id, c.event, c.payload, c.attempts, w.url, w.secret
+              FROM claimed c JOIN webhooks w ON w.id = c.webhook_id`
+	rows, err := s.db.QueryContext(ctx, query, now, now.Add(dispatchLease), dispatchBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []claim
+	for rows.Next() {
+		var c claim
+		if err := rows.Scan(&c.id, &c.event, &c.payload, &c.attempts, &c.url, &c.secret); err != nil {
+			s.logger.Error("Failed to scan webhook delivery", zap.Error(err))
+			return nil, err
+		}
+		claims = append(claims, c)
+	}
+	return claims, rows.Err()
+}
+
+//! \fn backoff(attempts int) time.Duration
+//! \brief Computes the delay before the next attempt, doubling per failed attempt.
+//! \param attempts Attempts made so far.
+//! \return Delay.
+func backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+//! \fn dispatch(ctx context.Context, c claim)
+//! \brief Sends a claimed delivery and records the outcome.
+//! \param ctx Context controlling the delivery.
+//! \param c Delivery to send.
+func (s *Service) dispatch(ctx context.Context, c claim) {
+	code, err := s.sender.send(ctx, c.url, c.secret, c.event, c.id, c.payload)
+	now := time.Now().UTC()
+
+	var statusCode *int
+	if code != 0 {
+		statusCode = &code
+	}
+	if err == nil {
+		query := `UPDATE webhook_deliveries SET status = $2, delivered_at = $3, next_attempt_at = NULL,
+                         last_status_code = $4, last_error = NULL
+                  WHERE id = $1`
+		if _, err := s.db.ExecContext(ctx, query, c.id, StatusSucceeded, now, statusCode); err != nil {
+			s.logger.Error("Failed to record webhook delivery", zap.Int64("delivery_id", c.id), zap.Error(err))
+		}
+		return
+	}
+
+	status, next := StatusPending, now.Add(backoff(c.attempts))
+	if c.attempts >= maxAttempts {
+		status = StatusFailed
+	}
+	s.logger.Warn("Webhook delivery failed", zap.Int64("delivery_id", c.id), zap.Int("attempts", c.attempts),
+		zap.Error(err))
+	query := `UPDATE webhook_deliveries SET status = $2,
+                     next_attempt_at = CASE WHEN $2 = 'pending' THEN $3::timestamp END,
+                     last_status_code = $4, last_error = $5
+              WHERE id = $1`
+	if _, err := s.db.ExecContext(ctx, query, c.id, status, next, statusCode, err.Error()); err != nil {
+		s.logger.Error("Failed to record webhook failure", zap.Int64("delivery_id", c.id), zap.Error(err))
+	}
+}
+
+//! \fn RunDispatcher(ctx context.Context)
+//! \brief Periodically sends pending webhook deliveries until the context is cancelled.
+//!        Safe to run on every API instance at once.
+//! \param ctx Context controlling the dispatcher's lifetime.
+func (s *Service) RunDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			claims, err := s.claimDue(ctx, time.Now().UTC())
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.Error("Failed to claim webhook deliveries", zap.Error(err))
+				}
+				break
+			}
+			for _, c := range claims {
+				s.dispatch(ctx, c)
+			}
+			if len(claims) < dispatchBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package webhooks
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"task-tracker/internal/models"
+	"task-tracker/internal/safehttp"
+	"task-tracker/internal/signing"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+//! \brief Event types a webhook can subscribe to.
+const (
+	EventTaskCreated = "task.created"
+	EventTaskUpdated = "task.updated"
+	EventTaskDeleted = "task.deleted"
+)
+
+//! \brief Delivery states.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const maxDeliveryLog = 100
+
+//! \var ErrInvalidURL
+//! \brief Returned when a webhook URL is not an absolute http(s) URL of a public host.
+var ErrInvalidURL = errors.New("url must be an http or https URL of a public host")
+
+//! \const webhookColumns
+//! \brief Column list matching the scan order of scanWebhook.
+const webhookColumns = `id, user_id, url, events, active, created_at`
+
+//! \const deliveryColumns
+//! \brief Column list matching the scan order of scanDelivery.
+const deliveryColumns = `id, webhook_id, event, payload, status, attempts, next_attempt_at,
+                         last_status_code, last_error, created_at, delivered_at`
+
+//! \struct Service
+//! \brief Handles webhook registration and delivery.
+type Service struct {
+	db     *sql.DB
+	sender *sender
+	logger *zap.Logger
+}
+
+//! \fn NewService(db *sql.DB, logger *zap.Logger) *Service
+//! \brief Initializes a new webhook service.
+//! \param db Database connection.
+//! \param logger Logger instance.
+//! \return Pointer to initialized Service.
+func NewService(db *sql.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		sender: newSender(),
+		logger: logger,
+	}
+}
+
+//! \interface rowScanner
+//! \brief Common interface of *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//! \fn scanWebhook(row rowScanner, w *models.Webhook) error
+//! \brief Scans the webhookColumns of a row into a webhook.
+//! \param row Row to scan.
+//! \param w Destination webhook.
+//! \return Error (if any).
+func scanWebhook(row rowScanner, w *models.Webhook) error {
+	return row.Scan(&w.ID, &w.UserID, &w.URL, pq.Array(&w.Events), &w.Active, &w.CreatedAt)
+}
+
+//! \fn scanDelivery(row rowScanner, d *models.WebhookDelivery) error
+//! \brief Scans the deliveryColumns of a row into a delivery.
+//! \param row Row to scan.
+//! \param d Destination delivery.
+//! \return Error (if any).
+func scanDelivery(row rowScanner, d *models.WebhookDelivery) error {
+	var payload []byte
+	err := row.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+	d.Payload = payload
+	return err
+}
+
+//! \fn checkURL(raw string) error
+//! \brief Verifies that a webhook URL is an absolute http(s) URL that does not name an internal host.
+//! \param raw URL to check.
+//! \return ErrInvalidURL (if invalid).
+func checkURL(raw string) error {
+	if !safehttp.PublicURL(raw) {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+//! \fn GetWebhooks(userID int) ([]models.Webhook, error)
+//! \brief Retrieves a user's webhooks; secrets are not included.
+//! \param userID ID of the user.
+//! \return List of webhooks and error (if any).
+func (s *Service) GetWebhooks(userID int) ([]models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE user_id = $1 ORDER BY id`
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		s.logger.Error("Failed to fetch webhooks", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		var w models.Webhook
+		if err := scanWebhook(rows, &w); err != nil {
+			s.logger.Error("Failed to scan webhook", zap.Error(err))
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate webhooks", zap.Error(err))
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+//! \fn CreateWebhook(w *models.Webhook) error
+//! \brief Registers a webhook with a freshly generated signing secret.
+//! \param w Webhook data; receives its stored values and the secret, which is only ever returned here.
+//! \return Error (if any).
+func (s *Service) CreateWebhook(w *models.Webhook) error {
+	if err := checkURL(w.URL); err != nil {
+		return err
+	}
+	secret, err := signing.NewSecret()
+	if err != nil {
+		s.logger.Error("Failed to generate webhook secret", zap.Error(err))
+		return err
+	}
+
+	query := `INSERT INTO webhooks (user_id, url, secret, events) VALUES ($1, $2, $3, $4)
+              RETURNING ` + webhookColumns
+	if err := scanWebhook(s.db.QueryRow(query, w.UserID, w.URL, secret, pq.Array(w.Events)), w); err != nil {
+		s.logger.Error("Failed to create webhook", zap.Error(err))
+		return err
+	}
+	w.Secret = secret
+
+	s.logger.Info("Webhook created", zap.Int("webhook_id", w.ID), zap.Int("user_id", w.UserID))
+	return nil
+}
+
+//! \fn UpdateWebhook(webhookID string, userID int, w *models.Webhook) error
+//! \brief Replaces a webhook's URL, subscribed events and active flag.
+//! \param webhookID ID of the webhook.
+//! \param userID ID of the user.
+//! \param w New webhook data; receives its stored values.
+//! \return Error (if any).
+func (s *Service) UpdateWebhook(webhookID string, userID int, w *models.Webhook) error {
+	if err := checkURL(w.URL); err != nil {
+		return err
+	}
+
+	query := `UPDATE webhooks SET url = $1, events = $2, active = $3 WHERE id = $4 AND user_id = $5
+              RETURNING ` + webhookColumns
+	err := scanWebhook(s.db.QueryRow(query, w.URL, pq.Array(w.Events), w.Active, webhookID, userID), w)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("Failed to update webhook", zap.Error(err))
+		}
+		return err
+	}
+
+	s.logger.Info("Webhook updated", zap.Int("webhook_id", w.ID), zap.Int("user_id", userID))
+	return nil
+}
+
+//! \fn DeleteWebhook(webhookID string, userID int) error
+//! \brief Removes a webhook together with its delivery log.
+//! \param webhookID ID of the webhook.
+//! \param userID ID of the user.
+//! \return Error (if any).
+func (s *Service) DeleteWebhook(webhookID string, userID int) error {
+	result, err := s.db.Exec(`DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, webhookID, userID)
+	if err != nil {
+		s.logger.Error("Failed to delete webhook", zap.Error(err))
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	s.logger.Info("Webhook deleted", zap.String("webhook_id", webhookID), zap.Int("user_id", userID))
+	return nil
+}
+
+//! \fn checkWebhook(webhookID string, userID int) error
+//! \brief Verifies that a webhook belongs to the user.
+//! \param webhookID ID of the webhook.
+//! \param userID ID of the user.
+//! \return sql.ErrNoRows or another error (if any).
+func (s *Service) checkWebhook(webhookID string, userID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = $1 AND user_id = $2)`
+	if err := s.db.QueryRow(query, webhookID, userID).Scan(&exists); err != nil {
+		s.logger.Error("Failed to check webhook ownership", zap.Error(err))
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+//! \fn GetDeliveries(webhookID string, userID int) ([]models.WebhookDelivery, error)
+//! \brief Retrieves the latest deliveries of a webhook, newest first.
+//! \param webhookID ID of the webhook.
+//! \param userID ID of the user.
+//! \return List of deliveries and error (if any).
+func (s *Service) GetDeliveries(webhookID string, userID int) ([]models.WebhookDelivery, error) {
+	if err := s.checkWebhook(webhookID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+              WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2`
+	rows, err := s.db.Query(query, webhookID, maxDeliveryLog)
+	if err != nil {
+		s.logger.Error("Failed to fetch webhook deliveries", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := scanDelivery(rows, &d); err != nil {
+			s.logger.Error("Failed to scan webhook delivery", zap.Error(err))
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to iterate webhook deliveries", zap.Error(err))
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+//! \fn Redeliver(webhookID, deliveryID string, userID int) (*models.WebhookDelivery, error)
+//! \brief Queues a new delivery with the same event and payload as an earlier one.
+//! \param webhookID ID of the webhook.
+//! \param deliveryID ID of the delivery to repeat.
+//! \param userID ID of the user.
+//! \return New delivery and error (if any).
+func (s *Service) Redeliver(webhookID, deliveryID string, userID int) (*models.WebhookDelivery, error) {
+	if err := s.checkWebhook(webhookID, userID); err != nil {
+		return nil, err
+	}
+
+	var d models.WebhookDelivery
+	query := `INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at)
+              SELECT webhook_id, event, payload, $3 FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2
+              RETURNING ` + deliveryColumns
+	if err := scanDelivery(s.db.QueryRow(query, deliveryID, webhookID, time.Now().UTC()), &d); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("Failed to queue redelivery", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Webhook redelivery queued", zap.Int64("delivery_id", d.ID), zap.String("original_id", deliveryID))
+	return &d, nil
+}
+
+//! \fn Enqueue(tx *sql.Tx, userID int, event string, payload []byte) error
+//! \brief Queues an event for every active webhook of the user subscribed to it,
+//!        within the transaction of the change that caused it.
+//! \param tx Open transaction.
+//! \param userID ID of the user owning the changed resource.
+//! \param event Event type.
+//! \param payload JSON payload.
+//! \return Error (if any).
+func Enqueue(tx *sql.Tx, userID int, event string, payload []byte) error {
+	query := `INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at)
+              SELECT id, $2::text, $3, $4 FROM webhooks WHERE user_id = $1 AND active AND $2::text = ANY(events)`
+	_, err := tx.Exec(query, userID, event, payload, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,30 @@
+package webhooks
+
+import "testing"
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://example.com/hook", false},
+		{"http://example.com:8080/hook?x=1", false},
+		{"ftp://example.com/hook", true},
+		{"https://", true},
+		{"/relative/hook", true},
+		{"http://localhost:8080/hook", true},
+		{"http://api.localhost/hook", true},
+		{"http://127.0.0.1/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://10.0.0.5/hook", true},
+		{"http://93.184.216.34/hook", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := checkURL(tt.url); (err != nil) != tt.wantErr {
+				t.Errorf("checkURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package webhooks
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"task-tracker/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+//! \fn GetWebhooksHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list the user's webhooks.
+//! \param s Webhook service instance.
+//! \return Gin handler function.
+func GetWebhooksHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		webhooks, err := s.GetWebhooks(userID.(int))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		c.JSON(http.StatusOK, webhooks)
+	}
+}
+
+//! \fn CreateWebhookHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to register a webhook.
+//! \param s Webhook service instance.
+//! \return Gin handler function.
+func CreateWebhookHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var webhook models.Webhook
+		if !bindWebhook(c, s, &webhook) {
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		webhook.UserID = userID.(int)
+		if err := s.CreateWebhook(&webhook); err != nil {
+			respondError(c, err, "Failed to create webhook")
+			return
+		}
+		c.JSON(http.StatusCreated, webhook)
+	}
+}
+
+//! \fn UpdateWebhookHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to replace a webhook's settings.
+//! \param s Webhook service instance.
+//! \return Gin handler function.
+func UpdateWebhookHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var webhook models.Webhook
+		if !bindWebhook(c, s, &webhook) {
+			return
+		}
+
+		userID, _ := c.Get("user_id")
+		if err := s.UpdateWebhook(c.Param("id"), userID.(int), &webhook); err != nil {
+			respondError(c, err, "Failed to update webhook")
+			return
+		}
+		c.JSON(http.StatusOK, webhook)
+	}
+}
+
+//! \fn DeleteWebhookHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to delete a webhook.
+//! \param s Webhook service instance.
+//! \return Gin handler function.
+func DeleteWebhookHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		if err := s.DeleteWebhook(c.Param("id"), userID.(int)); err != nil {
+			respondError(c, err, "Failed to delete webhook")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+	}
+}
+
+//! \fn GetDeliveriesHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to list a webhook's delivery log.
+//! \param s Webhook service instance.
+//! \return Gin handler function.
+func GetDeliveriesHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		deliveries, err := s.GetDeliveries(c.Param("id"), userID.(int))
+		if err != nil {
+			respondError(c, err, "Internal server error")
+			return
+		}
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+//! \fn RedeliverHandler(s *Service) gin.HandlerFunc
+//! \brief Creates a Gin handler to queue a delivery again.
+//! \param s Webhook service instance.
+//! \return Gin handler function.
+func RedeliverHandler(s *Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		delivery, err := s.Redeliver(c.Param("id"), c.Param("delivery_id"), userID.(int))
+		if err != nil {
+			respondError(c, err, "Failed to queue redelivery")
+			return
+		}
+		c.JSON(http.StatusAccepted, delivery)
+	}
+}
+
+//! \fn bindWebhook(c *gin.Context, s *Service, webhook *models.Webhook) bool
+//! \brief Binds and validates a webhook request body.
+//! \param c Gin context.
+//! \param s Webhook service instance.
+//! \param webhook Destination webhook.
+//! \return False if a response has already been written.
+func bindWebhook(c *gin.Context, s *Service, webhook *models.Webhook) bool {
+	if err := c.ShouldBindJSON(webhook); err != nil {
+		s.logger.Warn("Invalid request body", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return false
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(webhook); err != nil {
+		s.logger.Warn("Validation failed", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+//! \fn respondError(c *gin.Context, err error, message string)
+//! \brief Writes the response for a failed webhook operation.
+//! \param c Gin context.
+//! \param err Error returned by the service.
+//! \param message Message used for unexpected errors.
+func respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	case errors.Is(err, ErrInvalidURL):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
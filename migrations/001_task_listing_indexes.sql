@@ -0,0 +1,11 @@
+/*! \migration 001_task_listing_indexes
+ *  \brief Adds the indexes behind keyset pagination of task lists.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE INDEX idx_tasks_user_created_at ON tasks (user_id, created_at, id);
+CREATE INDEX idx_tasks_user_due_date ON tasks (user_id, due_date NULLS FIRST, id);
+CREATE INDEX idx_tasks_user_priority ON tasks (user_id, priority, id);
+
+COMMIT;
@@ -0,0 +1,15 @@
+/*! \migration 002_task_search
+ *  \brief Adds the full-text search vector of tasks and its index.
+ *  Adding the generated column rewrites the tasks table, which is locked meanwhile.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_tasks_search_vector ON tasks USING GIN (search_vector);
+
+COMMIT;
@@ -0,0 +1,9 @@
+/*! \migration 003_nullable_due_date
+ *  \brief Clears the placeholder due date that undated tasks were stored with.
+ *  Tasks created without a due date used to be saved as 0001-01-01; they now keep due_date NULL.
+ */
+BEGIN;
+
+UPDATE tasks SET due_date = NULL WHERE due_date = '0001-01-01 00:00:00';
+
+COMMIT;
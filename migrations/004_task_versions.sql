@@ -0,0 +1,9 @@
+/*! \migration 004_task_versions
+ *  \brief Adds the version counter behind ETag and If-Match; existing tasks start at version 1.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+COMMIT;
@@ -0,0 +1,11 @@
+/*! \migration 005_trash
+ *  \brief Adds soft deletion of tasks.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,18 @@
+/*! \migration 006_task_events
+ *  \brief Adds the change history of tasks. Existing tasks start without history.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE TABLE task_events (
+    id BIGSERIAL PRIMARY KEY,
+    task_id INT NOT NULL,
+    actor_id INT REFERENCES users(id),
+    action VARCHAR(20) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_events_task_id ON task_events (task_id, id);
+
+COMMIT;
@@ -0,0 +1,9 @@
+/*! \migration 007_subtasks
+ *  \brief Adds the parent of subtasks; existing tasks stay top-level.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN parent_id INT REFERENCES tasks(id) ON DELETE SET NULL;
+
+COMMIT;
@@ -0,0 +1,19 @@
+/*! \migration 008_task_links
+ *  \brief Adds typed links between tasks.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE TABLE task_links (
+    id SERIAL PRIMARY KEY,
+    source_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    target_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_id, target_id, type),
+    CHECK (source_id <> target_id)
+);
+
+CREATE INDEX idx_task_links_target_id ON task_links (target_id);
+
+COMMIT;
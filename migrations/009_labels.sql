@@ -0,0 +1,24 @@
+/*! \migration 009_labels
+ *  \brief Adds per-user labels and their assignment to tasks.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE TABLE labels (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE task_labels (
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id INT NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX idx_task_labels_label_id ON task_labels (label_id);
+
+COMMIT;
@@ -0,0 +1,31 @@
+/*! \migration 010_projects
+ *  \brief Adds projects to an existing database and moves every task into its owner's Inbox.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE TABLE projects (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    name VARCHAR(100) NOT NULL,
+    is_inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    archived_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_projects_inbox ON projects (user_id) WHERE is_inbox;
+
+INSERT INTO projects (user_id, name, is_inbox)
+SELECT id, 'Inbox', TRUE FROM users;
+
+ALTER TABLE tasks ADD COLUMN project_id INT REFERENCES projects(id);
+
+UPDATE tasks t SET project_id = p.id
+FROM projects p
+WHERE p.user_id = t.user_id AND p.is_inbox;
+
+ALTER TABLE tasks ALTER COLUMN project_id SET NOT NULL;
+
+CREATE INDEX idx_tasks_project_id ON tasks (project_id);
+
+COMMIT;
@@ -0,0 +1,18 @@
+/*! \migration 011_task_comments
+ *  \brief Adds comment threads on tasks.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE TABLE task_comments (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    author_id INT NOT NULL REFERENCES users(id),
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    edited_at TIMESTAMP
+);
+
+CREATE INDEX idx_task_comments_task_id ON task_comments (task_id, id);
+
+COMMIT;
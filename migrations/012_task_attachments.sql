@@ -0,0 +1,20 @@
+/*! \migration 012_task_attachments
+ *  \brief Adds metadata of task attachments; blobs live in the storage backend.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE TABLE task_attachments (
+    id SERIAL PRIMARY KEY,
+    task_id INT REFERENCES tasks(id) ON DELETE SET NULL,
+    uploader_id INT NOT NULL REFERENCES users(id),
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_attachments_task_id ON task_attachments (task_id);
+
+COMMIT;
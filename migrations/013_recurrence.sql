@@ -0,0 +1,9 @@
+/*! \migration 013_recurrence
+ *  \brief Adds the recurrence rule of tasks; existing tasks do not repeat.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN recurrence TEXT;
+
+COMMIT;
@@ -0,0 +1,36 @@
+/*! \migration 014_reminders
+ *  \brief Adds task reminders and in-app notifications.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE TABLE task_reminders (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id),
+    offset_seconds INT,
+    remind_at TIMESTAMP,
+    channel VARCHAR(20) NOT NULL DEFAULT 'in_app',
+    webhook_url TEXT,
+    webhook_secret TEXT,
+    sent_at TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_reminders_pending ON task_reminders (remind_at) WHERE sent_at IS NULL;
+
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    task_id INT REFERENCES tasks(id) ON DELETE SET NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications (user_id, id);
+
+COMMIT;
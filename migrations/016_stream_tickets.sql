@@ -0,0 +1,26 @@
+/*! \migration 016_stream_tickets
+ *  \brief Adds the single-use tickets that authenticate event stream connections and stores the
+ *         owner on task history entries, so streams can replay them after a task is purged.
+ *  Existing entries take the owner of their task, or for purged tasks the user who acted on
+ *  them; entries without either cannot be delivered to anyone and are dropped.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE TABLE stream_tickets (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE task_events ADD COLUMN user_id INT REFERENCES users(id);
+UPDATE task_events e SET user_id = t.user_id FROM tasks t WHERE t.id = e.task_id;
+UPDATE task_events e SET user_id = (
+    SELECT a.actor_id FROM task_events a WHERE a.task_id = e.task_id AND a.actor_id IS NOT NULL LIMIT 1
+) WHERE e.user_id IS NULL;
+DELETE FROM task_events WHERE user_id IS NULL;
+ALTER TABLE task_events ALTER COLUMN user_id SET NOT NULL;
+CREATE INDEX idx_task_events_user_id ON task_events (user_id, id);
+
+COMMIT;
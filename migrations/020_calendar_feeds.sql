@@ -0,0 +1,14 @@
+/*! \migration 020_calendar_feeds
+ *  \brief Adds the secret tokens of iCalendar feeds.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE TABLE calendar_feeds (
+    user_id INT PRIMARY KEY REFERENCES users(id),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+
+COMMIT;
@@ -0,0 +1,35 @@
+/*! \migration 021_refresh_token_families
+ *  \brief Groups existing refresh tokens into token families and replaces the raw
+ *         tokens with their SHA-256 hashes, so issued tokens keep working.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ *  Requires PostgreSQL 13 or later for gen_random_uuid().
+ */
+BEGIN;
+
+CREATE TABLE token_families (
+    id UUID PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_token_families_user_id ON token_families (user_id);
+
+DELETE FROM refresh_tokens WHERE expires_at < CURRENT_TIMESTAMP;
+
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID;
+ALTER TABLE refresh_tokens ADD COLUMN used_at TIMESTAMP;
+
+UPDATE refresh_tokens SET family_id = gen_random_uuid();
+
+INSERT INTO token_families (id, user_id, created_at)
+SELECT family_id, user_id, created_at FROM refresh_tokens;
+
+UPDATE refresh_tokens SET token = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+ALTER TABLE refresh_tokens ADD FOREIGN KEY (family_id) REFERENCES token_families(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+COMMIT;
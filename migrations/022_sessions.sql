@@ -0,0 +1,14 @@
+/*! \migration 022_sessions
+ *  \brief Records the device and last use of each session (token family) and the session
+ *         each stream ticket was issued to.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+ALTER TABLE token_families ADD COLUMN user_agent VARCHAR(512);
+ALTER TABLE token_families ADD COLUMN ip VARCHAR(45);
+ALTER TABLE token_families ADD COLUMN last_used_at TIMESTAMP;
+
+ALTER TABLE stream_tickets ADD COLUMN session_id UUID;
+
+COMMIT;
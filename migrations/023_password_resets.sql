@@ -0,0 +1,22 @@
+/*! \migration 023_password_resets
+ *  \brief Adds password reset tokens and makes email addresses unique regardless of case.
+ *  Creating the index fails while two accounts share an address that differs only in case;
+ *  change one of them first.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+CREATE UNIQUE INDEX idx_users_email_lower ON users (LOWER(email));
+
+CREATE TABLE password_resets (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_resets_user_id ON password_resets (user_id);
+
+COMMIT;
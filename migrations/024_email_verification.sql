@@ -0,0 +1,19 @@
+/*! \migration 024_email_verification
+ *  \brief Adds email verification. Accounts created before verification existed are treated as
+ *         verified, so enabling REQUIRE_VERIFIED_EMAIL does not lock their owners out.
+ *  Fresh databases get the same structure from schema.sql and do not need this file.
+ */
+BEGIN;
+
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP);
+
+CREATE TABLE email_verifications (
+    user_id INT PRIMARY KEY REFERENCES users(id),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMIT;
@@ -6,9 +6,26 @@ CREATE TABLE users (
     username VARCHAR(50) NOT NULL UNIQUE,
     password_hash VARCHAR(255) NOT NULL,
     email VARCHAR(100) NOT NULL UNIQUE,
+    email_verified_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE UNIQUE INDEX idx_users_email_lower ON users (LOWER(email));
+
+/*! \table projects
+ *  \brief Stores projects grouping a user's tasks. Each user has exactly one Inbox.
+ */
+CREATE TABLE projects (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    name VARCHAR(100) NOT NULL,
+    is_inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    archived_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_projects_inbox ON projects (user_id) WHERE is_inbox;
+
 /*! \table tasks
  *  \brief Stores task information.
  */
@@ -20,16 +37,313 @@ CREATE TABLE tasks (
     status VARCHAR(20) NOT NULL DEFAULT 'pending',
     priority INT NOT NULL DEFAULT 1,
     due_date TIMESTAMP,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    version INT NOT NULL DEFAULT 1,
+    deleted_at TIMESTAMP,
+    parent_id INT REFERENCES tasks(id) ON DELETE SET NULL,
+    project_id INT NOT NULL REFERENCES projects(id),
+    recurrence TEXT,
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED
 );
 
+/*! \table token_families
+ *  \brief Groups the refresh tokens descended from one login, i.e. a session. Revoking a
+ *         family invalidates every token rotated from it and the session's access tokens.
+ */
+CREATE TABLE token_families (
+    id UUID PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    user_agent VARCHAR(512),
+    ip VARCHAR(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_token_families_user_id ON token_families (user_id);
+
 /*! \table refresh_tokens
- *  \brief Stores refresh tokens for authentication.
+ *  \brief Stores SHA-256 hashes of refresh tokens. A token is single-use: used_at is set
+ *         when it is rotated out, and the row is kept until expiry to detect reuse.
  */
 CREATE TABLE refresh_tokens (
     id SERIAL PRIMARY KEY,
     user_id INT NOT NULL REFERENCES users(id),
     token VARCHAR(255) NOT NULL UNIQUE,
+    family_id UUID NOT NULL REFERENCES token_families(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+/*! \table password_resets
+ *  \brief Stores SHA-256 hashes of password reset tokens. A user has at most one pending
+ *         token; it is single-use and expires after an hour.
+ */
+CREATE TABLE password_resets (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_password_resets_user_id ON password_resets (user_id);
+
+/*! \table email_verifications
+ *  \brief Stores the SHA-256 hash of a user's pending email verification token. Issuing a
+ *         new token replaces the previous one; the row is deleted once the address is verified.
+ */
+CREATE TABLE email_verifications (
+    user_id INT PRIMARY KEY REFERENCES users(id),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+/*! \table user_mfa
+ *  \brief A user's TOTP secret, encrypted with AES-GCM. Two-factor authentication is on once
+ *         enabled_at is set; last_step is the last accepted time step, so codes work only once.
+ *         failed_attempts counts wrong codes across logins; too many refuse codes until locked_until.
+ */
+CREATE TABLE user_mfa (
+    user_id INT PRIMARY KEY REFERENCES users(id),
+    secret BYTEA NOT NULL,
+    enabled_at TIMESTAMP,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+/*! \table mfa_recovery_codes
+ *  \brief SHA-256 hashes of one-time recovery codes standing in for a TOTP code.
+ */
+CREATE TABLE mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_mfa_recovery_codes_user_id ON mfa_recovery_codes (user_id);
+
+/*! \table mfa_challenges
+ *  \brief Logins that passed the password check and await a second factor. A challenge
+ *         expires after five minutes or five wrong codes.
+ */
+CREATE TABLE mfa_challenges (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+/*! \index tasks listing
+ *  \brief Supports keyset pagination of a user's tasks for each sort order.
+ */
+CREATE INDEX idx_tasks_user_created_at ON tasks (user_id, created_at, id);
+CREATE INDEX idx_tasks_user_due_date ON tasks (user_id, due_date NULLS FIRST, id);
+CREATE INDEX idx_tasks_user_priority ON tasks (user_id, priority, id);
+
+/*! \index tasks search
+ *  \brief Supports full-text search over task titles and descriptions.
+ */
+CREATE INDEX idx_tasks_search_vector ON tasks USING GIN (search_vector);
+
+/*! \index tasks trash
+ *  \brief Supports trash listing and the purge of expired trashed tasks.
+ */
+CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;
+
+/*! \table task_events
+ *  \brief Stores the change history of tasks. Rows outlive purged tasks for auditing and
+ *         keep the owner, so event streams can deliver and replay purges.
+ */
+CREATE TABLE task_events (
+    id BIGSERIAL PRIMARY KEY,
+    task_id INT NOT NULL,
+    user_id INT NOT NULL REFERENCES users(id),
+    actor_id INT REFERENCES users(id),
+    action VARCHAR(20) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_events_task_id ON task_events (task_id, id);
+CREATE INDEX idx_task_events_user_id ON task_events (user_id, id);
+
+/*! \table task_links
+ *  \brief Stores typed relations between tasks ("source blocks target", relates_to, duplicate_of).
+ */
+CREATE TABLE task_links (
+    id SERIAL PRIMARY KEY,
+    source_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    target_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (source_id, target_id, type),
+    CHECK (source_id <> target_id)
+);
+
+CREATE INDEX idx_task_links_target_id ON task_links (target_id);
+
+/*! \table labels
+ *  \brief Stores per-user labels.
+ */
+CREATE TABLE labels (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+/*! \table task_labels
+ *  \brief Attaches labels to tasks.
+ */
+CREATE TABLE task_labels (
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id INT NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX idx_task_labels_label_id ON task_labels (label_id);
+
+CREATE INDEX idx_tasks_project_id ON tasks (project_id);
+
+/*! \table task_comments
+ *  \brief Stores discussion comments on tasks.
+ */
+CREATE TABLE task_comments (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    author_id INT NOT NULL REFERENCES users(id),
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    edited_at TIMESTAMP
+);
+
+CREATE INDEX idx_task_comments_task_id ON task_comments (task_id, id);
+
+/*! \table task_attachments
+ *  \brief Stores metadata of files attached to tasks; blobs live in the storage backend.
+ *         Rows whose task is gone keep a NULL task_id until their blob is cleaned up.
+ */
+CREATE TABLE task_attachments (
+    id SERIAL PRIMARY KEY,
+    task_id INT REFERENCES tasks(id) ON DELETE SET NULL,
+    uploader_id INT NOT NULL REFERENCES users(id),
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_attachments_task_id ON task_attachments (task_id);
+
+/*! \table task_reminders
+ *  \brief Stores task reminders. Relative reminders keep their offset and have remind_at
+ *         recomputed whenever the task's due date changes.
+ */
+CREATE TABLE task_reminders (
+    id SERIAL PRIMARY KEY,
+    task_id INT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id),
+    offset_seconds INT,
+    remind_at TIMESTAMP,
+    channel VARCHAR(20) NOT NULL DEFAULT 'in_app',
+    webhook_url TEXT,
+    webhook_secret TEXT,
+    sent_at TIMESTAMP,
+    attempts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_reminders_pending ON task_reminders (remind_at) WHERE sent_at IS NULL;
+
+/*! \table notifications
+ *  \brief Stores in-app notifications shown to users.
+ */
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    task_id INT REFERENCES tasks(id) ON DELETE SET NULL,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications (user_id, id);
+
+/*! \table webhooks
+ *  \brief Stores user-registered endpoints for outgoing task events.
+ */
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    url TEXT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    events TEXT[] NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhooks_user_id ON webhooks (user_id);
+
+/*! \table webhook_deliveries
+ *  \brief Outbox and delivery log of webhook events. Rows are written in the transaction
+ *         of the task change, so an event is sent if and only if the change committed.
+ */
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_status_code INT,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, id);
+
+/*! \table stream_tickets
+ *  \brief Stores the hash of short-lived, single-use tickets that authenticate an event stream
+ *         connection in place of the access token. session_id is not a foreign key because
+ *         token families are cleaned up independently; revoked sessions are checked on use.
+ */
+CREATE TABLE stream_tickets (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    session_id UUID,
     expires_at TIMESTAMP NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+);
+
+/*! \table calendar_feeds
+ *  \brief Stores the secret token of each user's iCalendar feed, hashed.
+ *         Regenerating replaces the row, which revokes the old URL.
+ */
+CREATE TABLE calendar_feeds (
+    user_id INT PRIMARY KEY REFERENCES users(id),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);